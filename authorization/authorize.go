@@ -3,25 +3,30 @@ package authorization
 import (
 	"crypto/ed25519"
 	"encoding/base64"
+	"time"
 
 	"github.com/biscuit-auth/biscuit-go/v2"
 	"github.com/biscuit-auth/biscuit-go/v2/parser"
 )
 
-type SxTBiscuitStruct struct{
-
+type SxTBiscuitStruct struct {
 	Operation string
-	Resource string
+	Resource  string
 }
 
-
 // Create Biscuit Token
 func CreateBiscuitToken(capabilities []SxTBiscuitStruct, root *ed25519.PrivateKey) (biscuitToken string, status bool) {
-	
+	return CreateBiscuitTokenWithExpiry(capabilities, root, time.Time{})
+}
+
+// Create Biscuit Token which can't be used after `expiresAt`
+// A zero `expiresAt` creates a biscuit without expiry
+func CreateBiscuitTokenWithExpiry(capabilities []SxTBiscuitStruct, root *ed25519.PrivateKey, expiresAt time.Time) (biscuitToken string, status bool) {
+
 	var capabilityString string
 
 	builder := biscuit.NewBuilder(*root)
-	
+
 	for _, capability := range capabilities {
 		capabilityString = `sxt:capability("` + capability.Operation + `","` + capability.Resource + `")`
 
@@ -33,7 +38,18 @@ func CreateBiscuitToken(capabilities []SxTBiscuitStruct, root *ed25519.PrivateKe
 		if err != nil {
 			return "", false
 		}
-    }
+	}
+
+	if !expiresAt.IsZero() {
+		check, err := parser.FromStringCheck(`check if time($time), $time <= ` + expiresAt.UTC().Format(time.RFC3339))
+		if err != nil {
+			return "", false
+		}
+		err = builder.AddAuthorityCheck(check)
+		if err != nil {
+			return "", false
+		}
+	}
 
 	token, err := builder.Build()
 	if err != nil {
@@ -46,4 +62,4 @@ func CreateBiscuitToken(capabilities []SxTBiscuitStruct, root *ed25519.PrivateKe
 	}
 
 	return base64.URLEncoding.EncodeToString(tokenSerialized), true
-}
\ No newline at end of file
+}
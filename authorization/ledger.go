@@ -0,0 +1,287 @@
+package authorization
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biscuit-auth/biscuit-go/v2"
+)
+
+// Revocation list formats for ExportRevocationIds
+const (
+	// One hex encoded revocation id per line
+	RevocationListText = "text"
+
+	// {"revocationIds": ["<hex>", ...]}
+	RevocationListJSON = "json"
+)
+
+// A minted biscuit as recorded in a ledger.
+// Id is the hex encoded revocation id of the authority block
+type IssuedBiscuit struct {
+	Id            string             `json:"id"`
+	RevocationIds []string           `json:"revocationIds"`
+	Grantee       string             `json:"grantee"`
+	Capabilities  []SxTBiscuitStruct `json:"capabilities"`
+	IssuedAt      time.Time          `json:"issuedAt"`
+	ExpiresAt     time.Time          `json:"expiresAt,omitempty"`
+	RevokedAt     time.Time          `json:"revokedAt,omitempty"`
+}
+
+func (b IssuedBiscuit) Revoked() bool {
+	return !b.RevokedAt.IsZero()
+}
+
+// Keeps track of minted biscuits. FileLedger and SQLLedger are provided
+type BiscuitLedger interface {
+	Record(biscuit IssuedBiscuit) error
+	List() ([]IssuedBiscuit, error)
+
+	// Revoke marks the biscuit with the given id, or any of its revocation ids, as revoked
+	Revoke(id string, revokedAt time.Time) error
+
+	// IsRevoked reports if any of the revocation ids belongs to a revoked biscuit
+	IsRevoked(revocationIds []string) (bool, error)
+
+	// Prune drops the biscuits that expired before the given time and were never revoked.
+	// Revoked biscuits are kept for the revocation list
+	Prune(before time.Time) (pruned int, err error)
+}
+
+// Create Biscuit Token and record it in the ledger under the `grantee` label
+func CreateTrackedBiscuitToken(ledger BiscuitLedger, grantee string, capabilities []SxTBiscuitStruct, root *ed25519.PrivateKey, expiresAt time.Time) (biscuitToken string, err error) {
+	biscuitToken, status := CreateBiscuitTokenWithExpiry(capabilities, root, expiresAt)
+	if !status {
+		return "", errors.New("unable to create biscuit")
+	}
+
+	revocationIds, err := BiscuitRevocationIds(biscuitToken)
+	if err != nil {
+		return "", err
+	}
+
+	err = ledger.Record(IssuedBiscuit{
+		Id:            revocationIds[0],
+		RevocationIds: revocationIds,
+		Grantee:       grantee,
+		Capabilities:  capabilities,
+		IssuedAt:      time.Now().UTC(),
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return biscuitToken, nil
+}
+
+// Hex encoded revocation ids of a biscuit token, one per block
+func BiscuitRevocationIds(biscuitToken string) (revocationIds []string, err error) {
+	serialized, err := base64.URLEncoding.DecodeString(biscuitToken)
+	if err != nil {
+		return nil, fmt.Errorf("biscuit is not url safe base64: %w", err)
+	}
+
+	token, err := biscuit.Unmarshal(serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range token.RevocationIds() {
+		revocationIds = append(revocationIds, hex.EncodeToString(id))
+	}
+
+	return revocationIds, nil
+}
+
+// Check a biscuit token against the ledger
+func IsBiscuitRevoked(ledger BiscuitLedger, biscuitToken string) (revoked bool, err error) {
+	revocationIds, err := BiscuitRevocationIds(biscuitToken)
+	if err != nil {
+		return false, err
+	}
+
+	return ledger.IsRevoked(revocationIds)
+}
+
+// Export the revocation ids of every revoked biscuit, in RevocationListText or RevocationListJSON format
+func ExportRevocationIds(ledger BiscuitLedger, format string) (output []byte, err error) {
+	biscuits, err := ledger.List()
+	if err != nil {
+		return nil, err
+	}
+
+	revocationIds := []string{}
+	for _, issued := range biscuits {
+		if issued.Revoked() {
+			revocationIds = append(revocationIds, issued.RevocationIds...)
+		}
+	}
+	sort.Strings(revocationIds)
+
+	switch format {
+	case RevocationListText, "":
+		if len(revocationIds) == 0 {
+			return []byte{}, nil
+		}
+		return []byte(strings.Join(revocationIds, "\n") + "\n"), nil
+	case RevocationListJSON:
+		return json.Marshal(map[string][]string{"revocationIds": revocationIds})
+	}
+
+	return nil, fmt.Errorf("unknown revocation list format %q", format)
+}
+
+// Ledger stored as a JSON file
+// Note: the file is read on every call. For many services sharing a ledger, use SQLLedger
+type FileLedger struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (l *FileLedger) Record(biscuit IssuedBiscuit) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	biscuits, err := l.read()
+	if err != nil {
+		return err
+	}
+
+	return l.write(append(biscuits, biscuit))
+}
+
+func (l *FileLedger) List() ([]IssuedBiscuit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.read()
+}
+
+func (l *FileLedger) Revoke(id string, revokedAt time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	biscuits, err := l.read()
+	if err != nil {
+		return err
+	}
+
+	for idx := range biscuits {
+		if biscuits[idx].hasId(id) {
+			if !biscuits[idx].Revoked() {
+				biscuits[idx].RevokedAt = revokedAt.UTC()
+			}
+			return l.write(biscuits)
+		}
+	}
+
+	return fmt.Errorf("biscuit %s not found in ledger", id)
+}
+
+func (l *FileLedger) IsRevoked(revocationIds []string) (bool, error) {
+	biscuits, err := l.List()
+	if err != nil {
+		return false, err
+	}
+
+	return anyRevoked(biscuits, revocationIds), nil
+}
+
+func (l *FileLedger) Prune(before time.Time) (pruned int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	biscuits, err := l.read()
+	if err != nil {
+		return 0, err
+	}
+
+	kept := biscuits[:0]
+	for _, issued := range biscuits {
+		if issued.expiredBefore(before) {
+			pruned++
+			continue
+		}
+		kept = append(kept, issued)
+	}
+
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	return pruned, l.write(kept)
+}
+
+func (l *FileLedger) read() (biscuits []IssuedBiscuit, err error) {
+	content, err := os.ReadFile(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &biscuits)
+	return biscuits, err
+}
+
+func (l *FileLedger) write(biscuits []IssuedBiscuit) error {
+	content, err := json.MarshalIndent(biscuits, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial ledger
+	tmpPath := l.Path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, l.Path)
+}
+
+// Reports if the biscuit expired before the given time without being revoked
+func (b IssuedBiscuit) expiredBefore(before time.Time) bool {
+	return !b.Revoked() && !b.ExpiresAt.IsZero() && b.ExpiresAt.Before(before)
+}
+
+func (b IssuedBiscuit) hasId(id string) bool {
+	if b.Id == id {
+		return true
+	}
+
+	for _, revocationId := range b.RevocationIds {
+		if revocationId == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func anyRevoked(biscuits []IssuedBiscuit, revocationIds []string) bool {
+	for _, issued := range biscuits {
+		if !issued.Revoked() {
+			continue
+		}
+
+		for _, id := range revocationIds {
+			if issued.hasId(id) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package authorization
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Ledger stored in a database/sql table, for services sharing one ledger.
+// Call CreateTable once to create the ledger table
+type SQLLedger struct {
+	DB    *sql.DB
+	Table string
+
+	// DollarPlaceholders uses $1, $2 placeholders (postgres) instead of ?
+	DollarPlaceholders bool
+}
+
+// Create the ledger table if it doesn't exist
+func (l *SQLLedger) CreateTable() error {
+	_, err := l.DB.Exec(`CREATE TABLE IF NOT EXISTS ` + l.Table + ` (
+		id VARCHAR(128) PRIMARY KEY,
+		revocation_ids TEXT NOT NULL,
+		grantee VARCHAR(256) NOT NULL,
+		capabilities TEXT NOT NULL,
+		issued_at VARCHAR(64) NOT NULL,
+		expires_at VARCHAR(64) NOT NULL,
+		revoked_at VARCHAR(64) NOT NULL
+	)`)
+
+	return err
+}
+
+func (l *SQLLedger) Record(biscuit IssuedBiscuit) error {
+	capabilities, err := json.Marshal(biscuit.Capabilities)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, revocation_ids, grantee, capabilities, issued_at, expires_at, revoked_at) VALUES (%s)`,
+		l.Table, l.placeholders(1, 7))
+
+	_, err = l.DB.Exec(query, biscuit.Id, strings.Join(biscuit.RevocationIds, ","), biscuit.Grantee, string(capabilities),
+		formatLedgerTime(biscuit.IssuedAt), formatLedgerTime(biscuit.ExpiresAt), formatLedgerTime(biscuit.RevokedAt))
+
+	return err
+}
+
+func (l *SQLLedger) List() (biscuits []IssuedBiscuit, err error) {
+	return l.query(`SELECT id, revocation_ids, grantee, capabilities, issued_at, expires_at, revoked_at FROM ` + l.Table + ` ORDER BY issued_at`)
+}
+
+func (l *SQLLedger) Revoke(id string, revokedAt time.Time) error {
+	biscuits, err := l.List()
+	if err != nil {
+		return err
+	}
+
+	for _, issued := range biscuits {
+		if !issued.hasId(id) {
+			continue
+		}
+
+		if issued.Revoked() {
+			return nil
+		}
+
+		query := fmt.Sprintf(`UPDATE %s SET revoked_at = %s WHERE id = %s`, l.Table, l.placeholders(1, 1), l.placeholders(2, 1))
+		_, err = l.DB.Exec(query, formatLedgerTime(revokedAt), issued.Id)
+		return err
+	}
+
+	return fmt.Errorf("biscuit %s not found in ledger", id)
+}
+
+func (l *SQLLedger) IsRevoked(revocationIds []string) (bool, error) {
+	biscuits, err := l.query(`SELECT id, revocation_ids, grantee, capabilities, issued_at, expires_at, revoked_at FROM ` + l.Table + ` WHERE revoked_at <> ''`)
+	if err != nil {
+		return false, err
+	}
+
+	return anyRevoked(biscuits, revocationIds), nil
+}
+
+func (l *SQLLedger) Prune(before time.Time) (pruned int, err error) {
+	biscuits, err := l.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []interface{}
+	for _, issued := range biscuits {
+		if issued.expiredBefore(before) {
+			ids = append(ids, issued.Id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id IN (%s)`, l.Table, l.placeholders(1, len(ids)))
+	if _, err := l.DB.Exec(query, ids...); err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+func (l *SQLLedger) query(query string) (biscuits []IssuedBiscuit, err error) {
+	rows, err := l.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var issued IssuedBiscuit
+		var revocationIds, capabilities, issuedAt, expiresAt, revokedAt string
+
+		err = rows.Scan(&issued.Id, &revocationIds, &issued.Grantee, &capabilities, &issuedAt, &expiresAt, &revokedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		issued.RevocationIds = strings.Split(revocationIds, ",")
+		if err := json.Unmarshal([]byte(capabilities), &issued.Capabilities); err != nil {
+			return nil, err
+		}
+
+		if issued.IssuedAt, err = parseLedgerTime(issuedAt); err != nil {
+			return nil, err
+		}
+		if issued.ExpiresAt, err = parseLedgerTime(expiresAt); err != nil {
+			return nil, err
+		}
+		if issued.RevokedAt, err = parseLedgerTime(revokedAt); err != nil {
+			return nil, err
+		}
+
+		biscuits = append(biscuits, issued)
+	}
+
+	return biscuits, rows.Err()
+}
+
+// `count` placeholders starting at position `first`
+func (l *SQLLedger) placeholders(first, count int) string {
+	placeholders := make([]string, count)
+	for idx := range placeholders {
+		placeholders[idx] = "?"
+		if l.DollarPlaceholders {
+			placeholders[idx] = fmt.Sprintf("$%d", first+idx)
+		}
+	}
+
+	return strings.Join(placeholders, ", ")
+}
+
+// Times are stored as RFC3339 text so the table works on any database. Zero times are stored empty
+func formatLedgerTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseLedgerTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, value)
+}
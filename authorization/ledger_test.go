@@ -0,0 +1,82 @@
+package authorization
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileLedgerRevocation(t *testing.T) {
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	ledger := &FileLedger{Path: filepath.Join(t.TempDir(), "ledger.json")}
+
+	capabilities := []SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.t1"}}
+	token, err := CreateTrackedBiscuitToken(ledger, "analyst", capabilities, &privateKey, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	biscuits, err := ledger.List()
+	if err != nil || len(biscuits) != 1 || biscuits[0].Grantee != "analyst" {
+		t.Fatalf("expected one recorded biscuit, got %v %v", biscuits, err)
+	}
+
+	if revoked, _ := IsBiscuitRevoked(ledger, token); revoked {
+		t.Error("biscuit should not be revoked yet")
+	}
+
+	if err := ledger.Revoke(biscuits[0].Id, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked, _ := IsBiscuitRevoked(ledger, token); !revoked {
+		t.Error("biscuit should be revoked")
+	}
+
+	output, err := ExportRevocationIds(ledger, RevocationListText)
+	if err != nil || strings.TrimSpace(string(output)) != biscuits[0].Id {
+		t.Errorf("unexpected revocation list %q %v", output, err)
+	}
+}
+
+func TestFileLedgerPrune(t *testing.T) {
+	ledger := &FileLedger{Path: filepath.Join(t.TempDir(), "ledger.json")}
+	past := time.Now().Add(-time.Hour)
+
+	for _, issued := range []IssuedBiscuit{
+		{Id: "expired", ExpiresAt: past},
+		{Id: "revoked", ExpiresAt: past, RevokedAt: past},
+		{Id: "forever"},
+		{Id: "new", ExpiresAt: time.Now().Add(time.Hour)},
+	} {
+		if err := ledger.Record(issued); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Recording keeps the whole history
+	if biscuits, _ := ledger.List(); len(biscuits) != 4 {
+		t.Fatalf("expected 4 recorded biscuits, got %d", len(biscuits))
+	}
+
+	pruned, err := ledger.Prune(time.Now())
+	if err != nil || pruned != 1 {
+		t.Fatalf("expected one pruned biscuit, got %d: %v", pruned, err)
+	}
+
+	biscuits, err := ledger.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, issued := range biscuits {
+		ids = append(ids, issued.Id)
+	}
+	if strings.Join(ids, ",") != "revoked,forever,new" {
+		t.Errorf("expected the expired unrevoked biscuit to be pruned, got %v", ids)
+	}
+}
@@ -0,0 +1,240 @@
+package authorization
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/biscuit-auth/biscuit-go/v2"
+	"github.com/biscuit-auth/biscuit-go/v2/parser"
+)
+
+// Header read by the middleware when MiddlewareConfig.Header is empty
+const DefaultBiscuitHeader = "Biscuit"
+
+// Settings for Middleware
+type MiddlewareConfig struct {
+	// Header holding the biscuits, DefaultBiscuitHeader when empty.
+	// Several biscuits can be sent comma separated and a "Bearer " prefix is ignored
+	Header string
+
+	// RootKeys are the public keys biscuits may be signed with
+	RootKeys []ed25519.PublicKey
+
+	// Required returns the (operation, resource) pairs a request needs.
+	// When nil, requests only need a valid biscuit
+	Required func(r *http.Request) []SxTBiscuitStruct
+
+	// Ledger is optional. Revoked biscuits are rejected when set
+	Ledger BiscuitLedger
+
+	// Now is the time given to biscuit expiry checks, time.Now when nil
+	Now func() time.Time
+}
+
+type capabilitiesContextKey struct{}
+
+// Net/http middleware accepting the same biscuits users hold for SxT.
+// Biscuits are verified against the root keys, their checks are run (e.g. expiry) and the required
+// pairs are authorized against sxt:capability facts. Handlers get the capabilities with CapabilitiesFromContext.
+// Missing or invalid biscuits get 401, missing capabilities 403
+func Middleware(config MiddlewareConfig) func(http.Handler) http.Handler {
+	header := config.Header
+	if header == "" {
+		header = DefaultBiscuitHeader
+	}
+
+	now := config.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokens := biscuitsFromHeader(r.Header.Values(header))
+			if len(tokens) == 0 {
+				http.Error(w, "missing biscuit", http.StatusUnauthorized)
+				return
+			}
+
+			var required []SxTBiscuitStruct
+			if config.Required != nil {
+				required = config.Required(r)
+			}
+
+			capabilities, err := AuthorizeBiscuits(tokens, config.RootKeys, required, now())
+			if err != nil {
+				status := http.StatusUnauthorized
+				if errors.Is(err, ErrCapabilityMissing) {
+					status = http.StatusForbidden
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+
+			if config.Ledger != nil {
+				for _, token := range tokens {
+					revoked, err := IsBiscuitRevoked(config.Ledger, token)
+					if err != nil {
+						http.Error(w, "unable to check biscuit revocation", http.StatusInternalServerError)
+						return
+					}
+					if revoked {
+						http.Error(w, "biscuit revoked", http.StatusUnauthorized)
+						return
+					}
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), capabilitiesContextKey{}, capabilities)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Capabilities of the verified biscuits of a request, set by Middleware
+func CapabilitiesFromContext(ctx context.Context) (capabilities []SxTBiscuitStruct, ok bool) {
+	capabilities, ok = ctx.Value(capabilitiesContextKey{}).([]SxTBiscuitStruct)
+	return capabilities, ok
+}
+
+// Returned when the biscuits are valid but don't grant a required pair
+var ErrCapabilityMissing = errors.New("capability missing")
+
+// Verify biscuits and authorize every required pair with at least one of them.
+// Returns the capabilities granted by all the biscuits
+func AuthorizeBiscuits(tokens []string, rootKeys []ed25519.PublicKey, required []SxTBiscuitStruct, now time.Time) (capabilities []SxTBiscuitStruct, err error) {
+	verified := make([]verifiedBiscuit, 0, len(tokens))
+	for _, token := range tokens {
+		biscuit, err := verifyBiscuit(token, rootKeys, now)
+		if err != nil {
+			return nil, err
+		}
+		verified = append(verified, biscuit)
+		capabilities = append(capabilities, biscuit.capabilities...)
+	}
+
+	for _, pair := range required {
+		authorized := false
+		for _, biscuit := range verified {
+			if biscuit.authorize(pair, now) == nil {
+				authorized = true
+				break
+			}
+		}
+
+		if !authorized {
+			return nil, fmt.Errorf("%w: %s on %s", ErrCapabilityMissing, pair.Operation, pair.Resource)
+		}
+	}
+
+	return capabilities, nil
+}
+
+type verifiedBiscuit struct {
+	token        *biscuit.Biscuit
+	root         ed25519.PublicKey
+	capabilities []SxTBiscuitStruct
+}
+
+// Check the signature with the first matching root key, run the biscuit checks and read its capabilities
+func verifyBiscuit(token string, rootKeys []ed25519.PublicKey, now time.Time) (verified verifiedBiscuit, err error) {
+	serialized, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return verifiedBiscuit{}, errors.New("biscuit is not url safe base64")
+	}
+
+	verified.token, err = biscuit.Unmarshal(serialized)
+	if err != nil {
+		return verifiedBiscuit{}, errors.New("invalid biscuit")
+	}
+
+	var authorizer biscuit.Authorizer
+	for _, root := range rootKeys {
+		authorizer, err = verified.token.Authorizer(root)
+		if err == nil {
+			verified.root = root
+			break
+		}
+	}
+	if authorizer == nil {
+		return verifiedBiscuit{}, errors.New("biscuit not signed by a trusted key")
+	}
+
+	authorizer.AddFact(timeFact(now))
+	authorizer.AddPolicy(biscuit.DefaultAllowPolicy)
+	if err := authorizer.Authorize(); err != nil {
+		return verifiedBiscuit{}, fmt.Errorf("biscuit rejected: %w", err)
+	}
+
+	rule, _ := parser.FromStringRule(`capability($operation, $resource) <- sxt:capability($operation, $resource)`)
+	facts, err := authorizer.Query(rule)
+	if err != nil {
+		return verifiedBiscuit{}, err
+	}
+
+	for _, fact := range facts {
+		operation, okOperation := fact.IDs[0].(biscuit.String)
+		resource, okResource := fact.IDs[1].(biscuit.String)
+		if okOperation && okResource {
+			verified.capabilities = append(verified.capabilities, SxTBiscuitStruct{Operation: string(operation), Resource: string(resource)})
+		}
+	}
+
+	return verified, nil
+}
+
+// Authorize an (operation, resource) pair. Resources are compared lower cased and "*" operations match any operation
+func (v verifiedBiscuit) authorize(pair SxTBiscuitStruct, now time.Time) error {
+	authorizer, err := v.token.Authorizer(v.root)
+	if err != nil {
+		return err
+	}
+
+	authorizer.AddFact(timeFact(now))
+	authorizer.AddFact(stringFact("operation", pair.Operation))
+	authorizer.AddFact(stringFact("resource", strings.ToLower(pair.Resource)))
+
+	for _, policy := range []string{
+		`allow if operation($operation), resource($resource), sxt:capability($operation, $resource)`,
+		`allow if resource($resource), sxt:capability("*", $resource)`,
+	} {
+		p, err := parser.FromStringPolicy(policy)
+		if err != nil {
+			return err
+		}
+		authorizer.AddPolicy(p)
+	}
+
+	return authorizer.Authorize()
+}
+
+func timeFact(now time.Time) biscuit.Fact {
+	return biscuit.Fact{Predicate: biscuit.Predicate{Name: "time", IDs: []biscuit.Term{biscuit.Date(now)}}}
+}
+
+func stringFact(name, value string) biscuit.Fact {
+	return biscuit.Fact{Predicate: biscuit.Predicate{Name: name, IDs: []biscuit.Term{biscuit.String(value)}}}
+}
+
+func biscuitsFromHeader(values []string) (tokens []string) {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+			value = value[7:]
+		}
+
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+
+	return tokens
+}
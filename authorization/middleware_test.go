@@ -0,0 +1,61 @@
+package authorization
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	token, _ := CreateBiscuitToken([]SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.blocks"}}, &privateKey)
+	expired, _ := CreateBiscuitTokenWithExpiry([]SxTBiscuitStruct{{Operation: "*", Resource: "eth.blocks"}}, &privateKey, time.Now().Add(-time.Minute))
+	untrusted, _ := CreateBiscuitToken([]SxTBiscuitStruct{{Operation: "*", Resource: "eth.blocks"}}, &otherKey)
+
+	handler := Middleware(MiddlewareConfig{
+		RootKeys: []ed25519.PublicKey{publicKey},
+		Required: func(r *http.Request) []SxTBiscuitStruct {
+			operation := "dql_select"
+			if r.Method == http.MethodPost {
+				operation = "dml_insert"
+			}
+			return []SxTBiscuitStruct{{Operation: operation, Resource: "ETH.BLOCKS"}}
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capabilities, ok := CapabilitiesFromContext(r.Context())
+		if !ok || len(capabilities) != 1 {
+			t.Errorf("unexpected capabilities in context %v", capabilities)
+		}
+	}))
+
+	tests := []struct {
+		method string
+		token  string
+		status int
+	}{
+		{http.MethodGet, token, http.StatusOK},
+		{http.MethodGet, "Bearer " + token, http.StatusOK},
+		{http.MethodPost, token, http.StatusForbidden},
+		{http.MethodGet, expired, http.StatusUnauthorized},
+		{http.MethodGet, untrusted, http.StatusUnauthorized},
+		{http.MethodGet, "", http.StatusUnauthorized},
+	}
+
+	for idx, test := range tests {
+		request := httptest.NewRequest(test.method, "/blocks", nil)
+		if test.token != "" {
+			request.Header.Set(DefaultBiscuitHeader, test.token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != test.status {
+			t.Errorf("test %d: expected %d, got %d %s", idx, test.status, recorder.Code, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package authorization
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role based access policy, written in YAML or JSON:
+//
+//	roles:
+//	  - name: analyst
+//	    expiry: 720h
+//	    grants:
+//	      - operations: [select]
+//	        resources: ["ETH.*"]
+//	  - name: ingestor
+//	    grants:
+//	      - operations: [insert]
+//	        resources: ["ETH.EVENTS"]
+type Policy struct {
+	Roles []Role `yaml:"roles" json:"roles"`
+}
+
+type Role struct {
+	Name string `yaml:"name" json:"name"`
+
+	// Expiry is a Go duration such as 24h. Empty for biscuits without expiry
+	Expiry string  `yaml:"expiry,omitempty" json:"expiry,omitempty"`
+	Grants []Grant `yaml:"grants" json:"grants"`
+}
+
+// Operations may be SxT operations (dql_select), their short names (select) or "*".
+// Resources are `schema.table` names or globs such as ETH.*
+type Grant struct {
+	Operations []string `yaml:"operations" json:"operations"`
+	Resources  []string `yaml:"resources" json:"resources"`
+}
+
+// Expands a resource glob into table names, e.g. by listing tables with the discovery APIs
+type ResourceResolver func(pattern string) (resources []string, err error)
+
+// Options for CompilePolicy. All fields are optional
+type CompileOptions struct {
+	// Resolve expands globs. Without it globs are put in the biscuit as written
+	Resolve ResourceResolver
+
+	// Ledger records every compiled biscuit, with the role name as grantee
+	Ledger BiscuitLedger
+
+	// Now is the start of role expiry, time.Now when zero
+	Now time.Time
+}
+
+// Biscuit compiled for one role
+type RoleBiscuit struct {
+	Role         string
+	Token        string
+	Capabilities []SxTBiscuitStruct
+	ExpiresAt    time.Time
+}
+
+// A permission granted or taken away between two policy versions, or a change of role expiry
+type PermissionChange struct {
+	Role      string
+	Operation string
+	Resource  string
+	Added     bool
+
+	// Expiry changes have no operation and resource. OldExpiry and NewExpiry are zero for biscuits without expiry
+	Expiry    bool
+	OldExpiry time.Duration
+	NewExpiry time.Duration
+}
+
+func (c PermissionChange) String() string {
+	if c.Expiry {
+		return fmt.Sprintf("~ %s expiry %s -> %s", c.Role, formatExpiry(c.OldExpiry), formatExpiry(c.NewExpiry))
+	}
+
+	sign := "-"
+	if c.Added {
+		sign = "+"
+	}
+
+	return fmt.Sprintf("%s %s %s %s", sign, c.Role, c.Operation, c.Resource)
+}
+
+func formatExpiry(expiry time.Duration) string {
+	if expiry == 0 {
+		return "none"
+	}
+
+	return expiry.String()
+}
+
+var operationAliases = map[string]string{
+	"create": "ddl_create",
+	"alter":  "ddl_alter",
+	"drop":   "ddl_drop",
+	"insert": "dml_insert",
+	"update": "dml_update",
+	"merge":  "dml_merge",
+	"delete": "dml_delete",
+	"select": "dql_select",
+}
+
+var sxtOperations = map[string]bool{
+	"*": true, "ddl_create": true, "ddl_alter": true, "ddl_drop": true,
+	"dml_insert": true, "dml_update": true, "dml_merge": true, "dml_delete": true, "dql_select": true,
+	"kafka_icm_create": true, "kafka_icm_read": true, "kafka_icm_update": true, "kafka_icm_delete": true,
+}
+
+// Read a policy file in YAML or JSON
+func LoadPolicyFile(filename string) (*Policy, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePolicy(content)
+}
+
+// Parse a policy in YAML or JSON. Unknown fields are rejected
+func ParsePolicy(content []byte) (*Policy, error) {
+	var policy Policy
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// Check role names, operations, resource globs and expiries
+func (p *Policy) Validate() error {
+	names := map[string]bool{}
+	for _, role := range p.Roles {
+		if role.Name == "" {
+			return errors.New("role without name")
+		}
+
+		if names[role.Name] {
+			return fmt.Errorf("role %s defined twice", role.Name)
+		}
+		names[role.Name] = true
+
+		if _, err := role.expiry(); err != nil {
+			return err
+		}
+
+		if len(role.Grants) == 0 {
+			return fmt.Errorf("role %s has no grants", role.Name)
+		}
+
+		for _, grant := range role.Grants {
+			if len(grant.Operations) == 0 || len(grant.Resources) == 0 {
+				return fmt.Errorf("role %s has a grant without operations or resources", role.Name)
+			}
+
+			for _, operation := range grant.Operations {
+				if _, err := normalizeOperation(operation); err != nil {
+					return fmt.Errorf("role %s: %w", role.Name, err)
+				}
+			}
+
+			for _, resource := range grant.Resources {
+				if _, err := path.Match(resource, ""); err != nil {
+					return fmt.Errorf("role %s: invalid resource glob %q", role.Name, resource)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Capabilities granted to each role, with globs expanded by resolve when given
+func (p *Policy) Permissions(resolve ResourceResolver) (permissions map[string][]SxTBiscuitStruct, err error) {
+	permissions = map[string][]SxTBiscuitStruct{}
+
+	for _, role := range p.Roles {
+		seen := map[SxTBiscuitStruct]bool{}
+		capabilities := []SxTBiscuitStruct{}
+
+		for _, grant := range role.Grants {
+			resources, err := expandResources(grant.Resources, resolve)
+			if err != nil {
+				return nil, fmt.Errorf("role %s: %w", role.Name, err)
+			}
+
+			for _, operation := range grant.Operations {
+				operation, _ = normalizeOperation(operation)
+				for _, resource := range resources {
+					capability := SxTBiscuitStruct{Operation: operation, Resource: resource}
+					if !seen[capability] {
+						seen[capability] = true
+						capabilities = append(capabilities, capability)
+					}
+				}
+			}
+		}
+
+		sort.Slice(capabilities, func(i, j int) bool {
+			if capabilities[i].Resource != capabilities[j].Resource {
+				return capabilities[i].Resource < capabilities[j].Resource
+			}
+			return capabilities[i].Operation < capabilities[j].Operation
+		})
+		permissions[role.Name] = capabilities
+	}
+
+	return permissions, nil
+}
+
+// Compile a policy into one biscuit per role, signed by root
+func CompilePolicy(policy *Policy, root *ed25519.PrivateKey, options CompileOptions) (biscuits []RoleBiscuit, err error) {
+	permissions, err := policy.Permissions(options.Resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	for _, role := range policy.Roles {
+		expiry, _ := role.expiry()
+
+		var expiresAt time.Time
+		if expiry > 0 {
+			expiresAt = now.Add(expiry)
+		}
+
+		capabilities := permissions[role.Name]
+		if len(capabilities) == 0 {
+			return nil, fmt.Errorf("role %s resolves to no resources", role.Name)
+		}
+
+		var token string
+		if options.Ledger != nil {
+			token, err = CreateTrackedBiscuitToken(options.Ledger, role.Name, capabilities, root, expiresAt)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			var status bool
+			token, status = CreateBiscuitTokenWithExpiry(capabilities, root, expiresAt)
+			if !status {
+				return nil, fmt.Errorf("unable to create biscuit for role %s", role.Name)
+			}
+		}
+
+		biscuits = append(biscuits, RoleBiscuit{Role: role.Name, Token: token, Capabilities: capabilities, ExpiresAt: expiresAt})
+	}
+
+	return biscuits, nil
+}
+
+// Permissions added and removed between two policy versions, per role, and expiry changes of roles in both versions
+func DiffPolicies(oldPolicy, newPolicy *Policy, resolve ResourceResolver) (changes []PermissionChange, err error) {
+	oldPermissions, err := oldPolicy.Permissions(resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	newPermissions, err := newPolicy.Permissions(resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := map[string]bool{}
+	for role := range oldPermissions {
+		roles[role] = true
+	}
+	for role := range newPermissions {
+		roles[role] = true
+	}
+
+	for role := range roles {
+		before := map[SxTBiscuitStruct]bool{}
+		for _, capability := range oldPermissions[role] {
+			before[capability] = true
+		}
+
+		after := map[SxTBiscuitStruct]bool{}
+		for _, capability := range newPermissions[role] {
+			after[capability] = true
+			if !before[capability] {
+				changes = append(changes, PermissionChange{Role: role, Operation: capability.Operation, Resource: capability.Resource, Added: true})
+			}
+		}
+
+		for _, capability := range oldPermissions[role] {
+			if !after[capability] {
+				changes = append(changes, PermissionChange{Role: role, Operation: capability.Operation, Resource: capability.Resource})
+			}
+		}
+	}
+
+	oldExpiries, err := oldPolicy.expiries()
+	if err != nil {
+		return nil, err
+	}
+
+	newExpiries, err := newPolicy.expiries()
+	if err != nil {
+		return nil, err
+	}
+
+	for role, newExpiry := range newExpiries {
+		if oldExpiry, ok := oldExpiries[role]; ok && oldExpiry != newExpiry {
+			changes = append(changes, PermissionChange{Role: role, Expiry: true, OldExpiry: oldExpiry, NewExpiry: newExpiry})
+		}
+	}
+
+	// Per role: expiry first, then permissions by resource and operation
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		if a.Expiry != b.Expiry {
+			return a.Expiry
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Operation != b.Operation {
+			return a.Operation < b.Operation
+		}
+		return !a.Added && b.Added
+	})
+
+	return changes, nil
+}
+
+// Expiry of every role
+func (p *Policy) expiries() (map[string]time.Duration, error) {
+	expiries := map[string]time.Duration{}
+	for _, role := range p.Roles {
+		expiry, err := role.expiry()
+		if err != nil {
+			return nil, err
+		}
+		expiries[role.Name] = expiry
+	}
+
+	return expiries, nil
+}
+
+func (r Role) expiry() (time.Duration, error) {
+	if r.Expiry == "" {
+		return 0, nil
+	}
+
+	expiry, err := time.ParseDuration(r.Expiry)
+	if err != nil || expiry <= 0 {
+		return 0, fmt.Errorf("role %s: invalid expiry %q", r.Name, r.Expiry)
+	}
+
+	return expiry, nil
+}
+
+func normalizeOperation(operation string) (string, error) {
+	operation = strings.ToLower(operation)
+	if alias, ok := operationAliases[operation]; ok {
+		return alias, nil
+	}
+
+	if !sxtOperations[operation] {
+		return "", fmt.Errorf("unknown operation %q", operation)
+	}
+
+	return operation, nil
+}
+
+// Biscuit resources are lower case. Globs are expanded when a resolver is given
+func expandResources(patterns []string, resolve ResourceResolver) (resources []string, err error) {
+	for _, pattern := range patterns {
+		if resolve == nil || !strings.ContainsAny(pattern, "*?[") {
+			resources = append(resources, strings.ToLower(pattern))
+			continue
+		}
+
+		matches, err := resolve(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			resources = append(resources, strings.ToLower(match))
+		}
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,99 @@
+package authorization
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+const analystPolicy = `
+roles:
+  - name: analyst
+    expiry: 24h
+    grants:
+      - operations: [select]
+        resources: ["ETH.*"]
+  - name: ingestor
+    grants:
+      - operations: [insert, dml_update]
+        resources: ["ETH.EVENTS"]
+`
+
+func TestCompilePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(analystPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := func(pattern string) ([]string, error) {
+		return []string{"ETH.BLOCKS", "ETH.EVENTS"}, nil
+	}
+
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	biscuits, err := CompilePolicy(policy, &privateKey, CompileOptions{Resolve: resolve})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(biscuits) != 2 || biscuits[0].Role != "analyst" || len(biscuits[0].Capabilities) != 2 || biscuits[0].ExpiresAt.IsZero() {
+		t.Fatalf("unexpected analyst biscuit %+v", biscuits)
+	}
+
+	if !biscuits[1].ExpiresAt.IsZero() || biscuits[1].Capabilities[0] != (SxTBiscuitStruct{Operation: "dml_insert", Resource: "eth.events"}) {
+		t.Errorf("unexpected ingestor biscuit %+v", biscuits[1])
+	}
+}
+
+func TestDiffPolicies(t *testing.T) {
+	oldPolicy, _ := ParsePolicy([]byte(analystPolicy))
+	newPolicy, err := ParsePolicy([]byte(`{"roles": [{"name": "analyst", "grants": [{"operations": ["select"], "resources": ["ETH.*", "BTC.*"]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := DiffPolicies(oldPolicy, newPolicy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"~ analyst expiry 24h0m0s -> none",
+		"+ analyst dql_select btc.*",
+		"- ingestor dml_insert eth.events",
+		"- ingestor dml_update eth.events",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, changes)
+	}
+	for idx, change := range changes {
+		if change.String() != expected[idx] {
+			t.Errorf("expected %q, got %q", expected[idx], change)
+		}
+	}
+}
+
+func TestDiffPoliciesExpiry(t *testing.T) {
+	oldPolicy, _ := ParsePolicy([]byte(analystPolicy))
+	newPolicy, err := ParsePolicy([]byte(strings.Replace(analystPolicy, "expiry: 24h", "expiry: 1440m", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changes, err := DiffPolicies(oldPolicy, newPolicy, nil); err != nil || len(changes) != 0 {
+		t.Errorf("expected the same expiry written differently to be no change, got %v: %v", changes, err)
+	}
+
+	newPolicy, _ = ParsePolicy([]byte(strings.Replace(analystPolicy, "expiry: 24h", "expiry: 720h", 1)))
+	changes, err := DiffPolicies(oldPolicy, newPolicy, nil)
+	if err != nil || len(changes) != 1 || changes[0].String() != "~ analyst expiry 24h0m0s -> 720h0m0s" {
+		t.Errorf("expected the longer expiry to be reported, got %v: %v", changes, err)
+	}
+}
+
+func TestParsePolicyRejectsUnknownOperations(t *testing.T) {
+	_, err := ParsePolicy([]byte(`roles: [{name: analyst, grants: [{operations: [read], resources: [ETH.BLOCKS]}]}]`))
+	if err == nil {
+		t.Error("expected an error for an unknown operation")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+)
+
+func init() {
+	commands["biscuits"] = command{
+		usage: "list, revoke or export biscuits recorded in a ledger file",
+		run:   runBiscuits,
+	}
+}
+
+// sxt biscuits list|revoke|export -ledger <file>
+func runBiscuits(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: sxt biscuits list|revoke <id>|export [-format text|json] -ledger <file>")
+	}
+
+	flags := flag.NewFlagSet("biscuits "+args[0], flag.ContinueOnError)
+	ledgerPath := flags.String("ledger", "./tmp/biscuit-ledger.json", "Ledger file")
+	format := flags.String("format", authorization.RevocationListText, "Export format: text or json")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	ledger := &authorization.FileLedger{Path: *ledgerPath}
+
+	switch args[0] {
+	case "list":
+		biscuits, err := ledger.List()
+		if err != nil {
+			return err
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tGRANTEE\tCAPABILITIES\tEXPIRES\tREVOKED")
+		for _, issued := range biscuits {
+			var capabilities []string
+			for _, capability := range issued.Capabilities {
+				capabilities = append(capabilities, capability.Operation+":"+capability.Resource)
+			}
+
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", issued.Id, issued.Grantee, strings.Join(capabilities, ","),
+				formatTime(issued.ExpiresAt), formatTime(issued.RevokedAt))
+		}
+		return writer.Flush()
+
+	case "revoke":
+		if flags.NArg() != 1 {
+			return errors.New("usage: sxt biscuits revoke [-ledger <file>] <id>")
+		}
+		return ledger.Revoke(flags.Arg(0), time.Now())
+
+	case "export":
+		output, err := authorization.ExportRevocationIds(ledger, *format)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(output)
+		return err
+	}
+
+	return fmt.Errorf("unknown biscuits command %q", args[0])
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlvalidate"
+)
+
+func init() {
+	commands["exec"] = command{
+		usage: "run a SQL script statement by statement",
+		run:   runExec,
+	}
+}
+
+// sxt exec [-key <base64 private key> | -user <user id>] [-origin <app>] [-continue] [-preflight] <script file | ->
+func runExec(args []string) error {
+	flags := flag.NewFlagSet("exec", flag.ContinueOnError)
+	key := flags.String("key", "", "Standard base64 encoded private key minting a biscuit per statement")
+	userId := flags.String("user", "", "User id whose registered biscuits are used when -key is not given")
+	origin := flags.String("origin", "sxt-cli", "Origin app sent to the gateway")
+	continueOnError := flags.Bool("continue", false, "Run the remaining statements after a failure")
+	preflight := flags.Bool("preflight", false, "Validate the whole script against the discovery catalog before running it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: sxt exec [-key <base64 private key> | -user <user id>] [-continue] [-preflight] <script file | ->")
+	}
+
+	client, err := cliClient(*origin, *key, *userId)
+	if err != nil {
+		return err
+	}
+
+	script, err := readScript(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *preflight {
+		if err := preflightScript(script); err != nil {
+			return err
+		}
+	}
+
+	results, err := client.ExecScript(context.Background(), script, sqlcore.ScriptOptions{ContinueOnError: *continueOnError})
+	for _, result := range results {
+		printStatementResult(result)
+	}
+
+	return err
+}
+
+// Print the problems of a script, failing when it has errors so nothing runs
+func preflightScript(script string) error {
+	validator := sqlvalidate.New(sqlvalidate.DiscoveryCatalog{})
+	problems, err := validator.Validate(script)
+	if err != nil {
+		return err
+	}
+
+	errorCount := 0
+	for _, problem := range problems {
+		fmt.Printf("-- %s\n", problem)
+		if problem.Severity == sqlvalidate.Error {
+			errorCount++
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("preflight found %d errors, nothing was run", errorCount)
+	}
+
+	return nil
+}
+
+// Client minting biscuits with a private key, or using the biscuits registered for a user
+func cliClient(origin, key, userId string) (*sqlcore.Client, error) {
+	client := sqlcore.NewClient(origin)
+
+	switch {
+	case key != "":
+		privateKey, err := decodePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		client.MintKey = privateKey
+
+	case userId != "":
+		registry, err := sqlcore.NewBiscuitRegistry(sqlcore.FileBiscuitStore{UserId: userId})
+		if err != nil {
+			return nil, err
+		}
+		client.Biscuits = registry
+
+	default:
+		return nil, errors.New("-key or -user is required")
+	}
+
+	return client, nil
+}
+
+// Read a script file, or standard input for -
+func readScript(filename string) (string, error) {
+	var content []byte
+	var err error
+	if filename == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(filename)
+	}
+
+	return string(content), err
+}
+
+func printStatementResult(result sqlcore.StatementResult) {
+	if result.Err != nil {
+		fmt.Printf("-- %d (%s): error: %v\n", result.Index+1, result.Pos, result.Err)
+		return
+	}
+
+	exec := result.Result
+	switch {
+	case exec.Rows != nil:
+		fmt.Printf("-- %d (%s): %d rows\n", result.Index+1, result.Pos, len(exec.Rows.Rows))
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(exec.Rows.ColumnNames(), "\t"))
+		for _, row := range exec.Rows.Rows {
+			values := make([]string, len(row.Values()))
+			for idx, value := range row.Values() {
+				if value == nil {
+					values[idx] = "NULL"
+				} else {
+					values[idx] = fmt.Sprint(value)
+				}
+			}
+			fmt.Fprintln(writer, strings.Join(values, "\t"))
+		}
+		writer.Flush()
+
+	case exec.Counted:
+		fmt.Printf("-- %d (%s): %s, %d rows affected\n", result.Index+1, result.Pos, exec.Operation, exec.RowsAffected)
+
+	default:
+		fmt.Printf("-- %d (%s): %s ok\n", result.Index+1, result.Pos, exec.Operation)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+func init() {
+	commands["export"] = command{
+		usage: "write the DDL of a schema from its discovery metadata",
+		run:   runExport,
+	}
+}
+
+// sxt export [-dir <dir>] <SCHEMA>
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	dir := flags.String("dir", "", "Write one file per table and view in this directory instead of one script to standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: sxt export [-dir <dir>] <SCHEMA>")
+	}
+
+	export, err := sqlcore.NewClient("sxt-cli").ExportSchemaDDL(context.Background(), flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		return export.WriteScript(os.Stdout)
+	}
+
+	filenames, err := export.WriteFiles(*dir)
+	for _, filename := range filenames {
+		fmt.Println(filename)
+	}
+
+	return err
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/migrate"
+)
+
+func init() {
+	commands["migrate"] = command{
+		usage: "apply, revert or list schema migrations",
+		run:   runMigrate,
+	}
+}
+
+const migrateUsage = "usage: sxt migrate [-dir <dir>] -table <SCHEMA.TABLE> [-key <base64 private key> | -user <user id>] up | down | status | redo | to <version>"
+
+// sxt migrate [-dir <dir>] -table <tracking table> [-key | -user] up | down | status | redo | to <version>
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := flags.String("dir", "migrations", "Directory of <version>_<name>.up.sql and .down.sql files")
+	table := flags.String("table", "", "SCHEMA.TABLE recording applied migrations, created on first use")
+	key := flags.String("key", "", "Standard base64 encoded private key minting a biscuit per statement")
+	userId := flags.String("user", "", "User id whose registered biscuits are used when -key is not given")
+	origin := flags.String("origin", "sxt-cli", "Origin app sent to the gateway")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 || *table == "" {
+		return errors.New(migrateUsage)
+	}
+
+	client, err := cliClient(*origin, *key, *userId)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := migrate.LoadDir(*dir)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := migrate.New(client, *table, migrations)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var changed []migrate.Migration
+	switch action := flags.Arg(0); {
+	case action == "status" && flags.NArg() == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatuses(statuses)
+		return nil
+
+	case action == "up" && flags.NArg() == 1:
+		changed, err = migrator.Up(ctx)
+
+	case action == "down" && flags.NArg() == 1:
+		changed, err = migrator.Down(ctx)
+
+	case action == "redo" && flags.NArg() == 1:
+		var redone *migrate.Migration
+		if redone, err = migrator.Redo(ctx); redone != nil {
+			changed = []migrate.Migration{*redone}
+		}
+
+	case action == "to" && flags.NArg() == 2:
+		version, parseErr := strconv.ParseInt(flags.Arg(1), 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("invalid version %q", flags.Arg(1))
+		}
+		changed, err = migrator.To(ctx, version)
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	for _, migration := range changed {
+		fmt.Printf("%s %d_%s\n", flags.Arg(0), migration.Version, migration.Name)
+	}
+
+	if err == nil && len(changed) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+
+	return err
+}
+
+func printStatuses(statuses []migrate.Status) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		switch {
+		case status.Missing:
+			state = "missing"
+		case status.Drifted:
+			state = "drifted"
+		case status.Applied:
+			state = "applied"
+		}
+
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+
+	writer.Flush()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+)
+
+func init() {
+	commands["policy"] = command{
+		usage: "compile a role policy file into biscuits, or diff two policy versions",
+		run:   runPolicy,
+	}
+}
+
+// sxt policy compile -key <base64 private key> [-resolve] [-ledger <file>] <policy file>
+// sxt policy diff [-resolve] <old policy file> <new policy file>
+func runPolicy(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: sxt policy compile|diff [flags] <files>")
+	}
+
+	flags := flag.NewFlagSet("policy "+args[0], flag.ContinueOnError)
+	key := flags.String("key", "", "Standard base64 encoded private key signing the biscuits")
+	resolve := flags.Bool("resolve", false, "Expand resource globs with the discovery APIs. Needs the accessToken environment variable")
+	ledgerPath := flags.String("ledger", "", "(Optional) Ledger file to record compiled biscuits in")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var resolver authorization.ResourceResolver
+	if *resolve {
+		resolver = resolveTables
+	}
+
+	switch args[0] {
+	case "compile":
+		if flags.NArg() != 1 {
+			return errors.New("usage: sxt policy compile -key <base64 private key> <policy file>")
+		}
+
+		privateKey, err := decodePrivateKey(*key)
+		if err != nil {
+			return err
+		}
+
+		policy, err := authorization.LoadPolicyFile(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		options := authorization.CompileOptions{Resolve: resolver}
+		if *ledgerPath != "" {
+			options.Ledger = &authorization.FileLedger{Path: *ledgerPath}
+		}
+
+		biscuits, err := authorization.CompilePolicy(policy, &privateKey, options)
+		if err != nil {
+			return err
+		}
+
+		for _, biscuit := range biscuits {
+			fmt.Printf("%s\t%s\n", biscuit.Role, biscuit.Token)
+		}
+		return nil
+
+	case "diff":
+		if flags.NArg() != 2 {
+			return errors.New("usage: sxt policy diff <old policy file> <new policy file>")
+		}
+
+		oldPolicy, err := authorization.LoadPolicyFile(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		newPolicy, err := authorization.LoadPolicyFile(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+
+		changes, err := authorization.DiffPolicies(oldPolicy, newPolicy, resolver)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown policy command %q", args[0])
+}
+
+// Private keys are accepted as 32 byte seeds or 64 byte ed25519 keys
+func decodePrivateKey(encoded string) (ed25519.PrivateKey, error) {
+	if encoded == "" {
+		return nil, errors.New("-key is required")
+	}
+
+	keyBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("base64 std encoded private key expected")
+	}
+
+	switch len(keyBytes) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(keyBytes), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(keyBytes), nil
+	}
+
+	return nil, fmt.Errorf("private key has %d bytes, expected 32 or 64", len(keyBytes))
+}
+
+// Expand SCHEMA.<glob> with the tables of the schema
+func resolveTables(pattern string) (resources []string, err error) {
+	parts := strings.SplitN(strings.ToUpper(pattern), ".", 2)
+	if len(parts) != 2 || strings.ContainsAny(parts[0], "*?[") {
+		return nil, fmt.Errorf("resource glob %q should be SCHEMA.<glob>", pattern)
+	}
+
+	tables, errMsg, status := discovery.ListTables(parts[0], discovery.ScopeAll, "")
+	if !status {
+		return nil, errors.New(errMsg)
+	}
+
+	for _, table := range tables {
+		if matched, _ := path.Match(parts[1], table.Table); matched {
+			resources = append(resources, parts[0]+"."+table.Table)
+		}
+	}
+
+	return resources, nil
+}
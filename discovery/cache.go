@@ -0,0 +1,165 @@
+package discovery
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discovery endpoint, used to set per endpoint cache TTLs
+type Endpoint string
+
+const (
+	EndpointSchemas               Endpoint = "schema"
+	EndpointTables                Endpoint = "table"
+	EndpointColumns               Endpoint = "table/column"
+	EndpointIndexes               Endpoint = "table/index"
+	EndpointPrimaryKeys           Endpoint = "table/primarykey"
+	EndpointRelations             Endpoint = "table/relations"
+	EndpointPrimaryKeyReferences  Endpoint = "refs/primarykey"
+	EndpointForeignKeyReferences  Endpoint = "refs/foreignkey"
+	EndpointBlockchains           Endpoint = "blockchains"
+	EndpointBlockchainSchemas     Endpoint = "blockchains/schemas"
+	EndpointBlockchainInformation Endpoint = "blockchains/meta"
+	EndpointViews                 Endpoint = "views"
+)
+
+// Cache of discovery responses, turned on with UseCache.
+// Concurrent lookups of the same resource share a single request.
+// Failed lookups are not cached
+type Cache struct {
+	// TTL applies to endpoints missing from EndpointTTL
+	TTL time.Duration
+
+	// EndpointTTL overrides TTL per endpoint. Zero turns caching off for an endpoint.
+	// Set it before the cache is used
+	EndpointTTL map[Endpoint]time.Duration
+
+	// now reads the clock, time.Now unless a test sets it
+	now func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// Response of a lookup. done is closed once the request is over
+type cacheEntry struct {
+	endpoint Endpoint
+	schema   string
+	table    string
+
+	body    []byte
+	errMsg  string
+	status  bool
+	expires time.Time
+	done    chan struct{}
+}
+
+var (
+	cacheMutex sync.RWMutex
+	cache      *Cache
+)
+
+// Create a cache keeping responses for ttl
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{TTL: ttl, now: time.Now}
+}
+
+// Cache the discovery responses of the process in cache. nil turns caching off
+func UseCache(c *Cache) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	cache = c
+}
+
+// Drop the cached responses about a schema, or only about a table of it when table is set.
+// An empty schema drops everything. Does nothing when caching is off
+func Invalidate(schema, table string) {
+	cacheMutex.RLock()
+	c := cache
+	cacheMutex.RUnlock()
+
+	if c != nil {
+		c.Invalidate(schema, table)
+	}
+}
+
+// Drop the cached responses about a schema, or only about a table of it when table is set.
+// Responses spanning schemas, such as the schema and view lists, are always dropped,
+// as are key references of the schema since they involve other tables. An empty schema drops everything
+func (c *Cache) Invalidate(schema, table string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, entry := range c.entries {
+		if schema == "" || entry.schema == "" {
+			delete(c.entries, key)
+			continue
+		}
+
+		if !strings.EqualFold(entry.schema, schema) {
+			continue
+		}
+
+		if table == "" || entry.table == "" || strings.EqualFold(entry.table, table) || entry.endpoint == EndpointPrimaryKeyReferences || entry.endpoint == EndpointForeignKeyReferences {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *Cache) ttl(endpoint Endpoint) time.Duration {
+	if ttl, ok := c.EndpointTTL[endpoint]; ok {
+		return ttl
+	}
+
+	return c.TTL
+}
+
+// Response of a lookup from the cache, or from fetch when it's missing or expired
+func (c *Cache) get(endpoint Endpoint, key string, query url.Values, fetch func() ([]byte, string, bool)) (body []byte, errMsg string, status bool) {
+	ttl := c.ttl(endpoint)
+	if ttl <= 0 {
+		return fetch()
+	}
+
+	c.mutex.Lock()
+	if c.entries == nil {
+		c.entries = map[string]*cacheEntry{}
+	}
+
+	// Entries without expiry are still being fetched
+	entry, ok := c.entries[key]
+	if ok && (entry.expires.IsZero() || c.clock().Before(entry.expires)) {
+		c.mutex.Unlock()
+		<-entry.done
+		return entry.body, entry.errMsg, entry.status
+	}
+
+	entry = &cacheEntry{endpoint: endpoint, schema: query.Get("schema"), table: query.Get("table"), done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mutex.Unlock()
+
+	entry.body, entry.errMsg, entry.status = fetch()
+
+	// An entry invalidated while it was fetched is no longer in the map and isn't stored
+	c.mutex.Lock()
+	entry.expires = c.clock().Add(ttl)
+	if !entry.status && c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+	c.mutex.Unlock()
+	close(entry.done)
+
+	return entry.body, entry.errMsg, entry.status
+}
+
+// Current time, also for caches built without NewCache
+func (c *Cache) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+
+	return c.now()
+}
@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if request.URL.Path == "/discover/table/column" {
+			<-release
+		}
+		w.Write([]byte(`[{"schema": "ETH", "table": "BLOCKS", "column": "ID"}]`))
+	}))
+	defer server.Close()
+	t.Setenv("BASEURL_GENERAL", server.URL)
+	t.Setenv("BASEURL_DISCOVERY", server.URL)
+
+	UseCache(&Cache{TTL: time.Minute, EndpointTTL: map[Endpoint]time.Duration{EndpointSchemas: 0}})
+	defer UseCache(nil)
+
+	// Concurrent lookups share one request
+	var wait sync.WaitGroup
+	for idx := 0; idx < 5; idx++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if columns, errMsg, status := ListColumns("ETH", "BLOCKS"); !status || len(columns) != 1 {
+				t.Errorf("unexpected columns %+v: %s", columns, errMsg)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wait.Wait()
+
+	ListColumns("ETH", "BLOCKS")
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+	// Another table is untouched by the invalidation
+	ListColumns("ETH", "TX")
+	Invalidate("eth", "blocks")
+	ListColumns("ETH", "BLOCKS")
+	ListColumns("ETH", "TX")
+	if requests != 3 {
+		t.Errorf("expected the invalidated table to be fetched again, got %d requests", requests)
+	}
+
+	Invalidate("ETH", "")
+	ListColumns("ETH", "TX")
+	if requests != 4 {
+		t.Errorf("expected the invalidated schema to be fetched again, got %d requests", requests)
+	}
+
+	// Schemas are not cached
+	ListSchemas(ScopeAll, "")
+	ListSchemas(ScopeAll, "")
+	if requests != 6 {
+		t.Errorf("expected uncached schema lookups, got %d requests", requests)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	t.Setenv("BASEURL_GENERAL", server.URL)
+	t.Setenv("BASEURL_DISCOVERY", server.URL)
+
+	now := time.Now()
+	cached := NewCache(time.Hour)
+	cached.now = func() time.Time { return now }
+	UseCache(cached)
+	defer UseCache(nil)
+
+	// Failures are not cached
+	if _, _, status := ListViews("", AllViews); status {
+		t.Fatal("expected the first lookup to fail")
+	}
+	ListViews("", AllViews)
+	ListViews("", AllViews)
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+
+	now = now.Add(59 * time.Minute)
+	ListViews("", AllViews)
+	if requests != 2 {
+		t.Errorf("expected the entry to be kept until it expires, got %d requests", requests)
+	}
+
+	now = now.Add(time.Minute)
+	ListViews("", AllViews)
+	if requests != 3 {
+		t.Errorf("expected the expired entry to be fetched again, got %d requests", requests)
+	}
+}
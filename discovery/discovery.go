@@ -1,9 +1,11 @@
 package discovery
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
@@ -11,182 +13,202 @@ import (
 )
 
 // List available namespaces in the blockchain
-func ListSchemas(scope, searchPattern string) (schemas string, errMsg string, status bool) {
-	tokenEndPoint := helpers.GetDiscoverEndpoint("schema") + "?scope=" + scope
-
+func ListSchemas(scope Scope, searchPattern string) (schemas []Schema, errMsg string, status bool) {
+	query := url.Values{"scope": {string(scope)}}
 	if searchPattern != "" {
-		tokenEndPoint += "&searchPattern=" + searchPattern
+		query.Set("searchPattern", searchPattern)
 	}
 
-	return executeRequest(tokenEndPoint)
+	return executeRequest[[]Schema](EndpointSchemas, helpers.GetDiscoverEndpoint("schema"), query)
 }
 
 /*
 List tables in a given schema
-Possible scope values -  ALL = all resources, PUBLIC = non-permissioned tables, PRIVATE = tables created by the requesting user
+Possible scope values -  ScopeAll = all resources, ScopePublic = non-permissioned tables, ScopePrivate = tables created by the requesting user
 */
-func ListTables(schema, scope, searchPattern string) (tables string, errMsg string, status bool) {
+func ListTables(schema string, scope Scope, searchPattern string) (tables []Table, errMsg string, status bool) {
 	re, r := helpers.CheckUpperCase(schema)
 	if !r {
-		return "", re, r
+		return nil, re, r
 	}
 
-	tableEndpoint := helpers.GetDiscoverEndpoint("table")
-	tokenEndPoint := fmt.Sprintf("%s?scope=%s", tableEndpoint, scope)
+	query := url.Values{"scope": {string(scope)}}
 	if schema != "" {
-		tokenEndPoint += "&schema=" + schema
+		query.Set("schema", schema)
 	}
 
 	if searchPattern != "" {
-		tokenEndPoint += "&searchPattern=" + searchPattern
+		query.Set("searchPattern", searchPattern)
 	}
 
-	return executeRequest(tokenEndPoint)
+	return executeRequest[[]Table](EndpointTables, helpers.GetDiscoverEndpoint("table"), query)
 }
 
 // List columns in a given schema and a table
-func ListColumns(schema, table string) (columns string, errMsg string, status bool) {
-	return listTableInfo("column", schema, table)
+func ListColumns(schema, table string) (columns []Column, errMsg string, status bool) {
+	return listTableInfo[Column](EndpointColumns, schema, table)
 }
 
 // List table index in a given schema and a table
-func ListTableIndex(schema, table string) (indexes string, errMsg string, status bool) {
-	return listTableInfo("index", schema, table)
+func ListTableIndex(schema, table string) (indexes []Index, errMsg string, status bool) {
+	return listTableInfo[Index](EndpointIndexes, schema, table)
 }
 
 // List table primary keys in a given schema and a table
-func ListTablePrimaryKey(schema, table string) (primaryKeys string, errMsg string, status bool) {
-	return listTableInfo("primarykey", schema, table)
+func ListTablePrimaryKey(schema, table string) (primaryKeys []PrimaryKey, errMsg string, status bool) {
+	return listTableInfo[PrimaryKey](EndpointPrimaryKeys, schema, table)
 }
 
-// List table primary keys in a given schema and a table
-func listTableInfo(infoType, schema, table string) (info string, errMsg string, status bool) {
-	queryParameters := []string{schema, table}
-
-	for _, field := range queryParameters {
-		message, isUpperCase := helpers.CheckUpperCase(field)
-		if !isUpperCase {
-			return "", message, isUpperCase
-		}
+// List table information in a given schema and a table.
+// The table is named as stored, which is lower or mixed case when it was created quoted
+func listTableInfo[T any](endpoint Endpoint, schema, table string) (info []T, errMsg string, status bool) {
+	if message, isUpperCase := helpers.CheckUpperCase(schema); !isUpperCase {
+		return nil, message, isUpperCase
 	}
 
-	tableEndpoint := helpers.GetDiscoverEndpoint("table")
-	tokenEndPoint := fmt.Sprintf("%s/%s?schema=%s&table=%s", tableEndpoint, infoType, schema, table)
+	if message, ok := helpers.CheckStoredName(table); !ok {
+		return nil, message, ok
+	}
 
-	return executeRequest(tokenEndPoint)
+	query := url.Values{"schema": {schema}, "table": {table}}
 
+	return executeRequest[[]T](endpoint, helpers.GetDiscoverEndpoint(string(endpoint)), query)
 }
 
 // List table relationships in a given schema and a table
-// Scope can be PRIVATE, PUBLIC, ALL
-func ListTableRelations(schema, scope string) (relations string, errMsg string, status bool) {
+// Scope can be ScopePrivate, ScopePublic, ScopeAll
+func ListTableRelations(schema string, scope Scope) (relations []Relation, errMsg string, status bool) {
 	re, r := helpers.CheckUpperCase(schema)
 	if !r {
-		return "", re, r
+		return nil, re, r
 	}
 
-	tableEndpoint := helpers.GetDiscoverEndpoint("table")
-	tokenEndPoint := fmt.Sprintf("%s/relations?schema=%s&scope=%s", tableEndpoint, schema, scope)
+	query := url.Values{"schema": {schema}, "scope": {string(scope)}}
 
-	return executeRequest(tokenEndPoint)
+	return executeRequest[[]Relation](EndpointRelations, helpers.GetDiscoverEndpoint(string(EndpointRelations)), query)
 }
 
 // List primary key references in a given schema and a table and a column
-func ListPrimaryKeyReferences(schema, table, column string) (primaryKeyReferences string, errMsg string, status bool) {
-	return listKeyReferences("primary", schema, table, column)
+func ListPrimaryKeyReferences(schema, table, column string) (primaryKeyReferences []KeyReference, errMsg string, status bool) {
+	return listKeyReferences(EndpointPrimaryKeyReferences, schema, table, column)
 }
 
 // List foreign key references in a given schema and a table and a column
-func ListForeignKeyReferences(schema, table, column string) (foreignKeyReferences string, errMsg string, status bool) {
-	return listKeyReferences("foreign", schema, table, column)
+func ListForeignKeyReferences(schema, table, column string) (foreignKeyReferences []KeyReference, errMsg string, status bool) {
+	return listKeyReferences(EndpointForeignKeyReferences, schema, table, column)
 }
 
-func listKeyReferences(keyReferenceType, schema, table, column string) (keyReferences string, errMsg string, status bool) {
-	queryParameters := []string{schema, table, column}
+// The table and column are named as stored, see listTableInfo
+func listKeyReferences(endpoint Endpoint, schema, table, column string) (keyReferences []KeyReference, errMsg string, status bool) {
+	if message, isUpperCase := helpers.CheckUpperCase(schema); !isUpperCase {
+		return nil, message, isUpperCase
+	}
 
-	for _, field := range queryParameters {
-		message, isUpperCase := helpers.CheckUpperCase(field)
-		if !isUpperCase {
-			return "", message, isUpperCase
+	for _, field := range []string{table, column} {
+		if message, ok := helpers.CheckStoredName(field); !ok {
+			return nil, message, ok
 		}
 	}
 
-	referenceKeyEndpoint := helpers.GetDiscoverEndpoint("refs")
-	tokenEndPoint := fmt.Sprintf("%s/%skey?schema=%s&table=%s&column=%s", referenceKeyEndpoint, keyReferenceType, schema, table, column)
-	return executeRequest(tokenEndPoint)
+	query := url.Values{"schema": {schema}, "table": {table}, "column": {column}}
+
+	return executeRequest[[]KeyReference](endpoint, helpers.GetDiscoverEndpoint(string(endpoint)), query)
 }
 
 // List Blockchains
-func ListBlockchains() (blockchains string, errMsg string, status bool) {
-	return listBlockchainInfo("", "")
+func ListBlockchains() (blockchains []Blockchain, errMsg string, status bool) {
+	return executeRequest[[]Blockchain](EndpointBlockchains, helpers.GetDiscoverEndpoint("blockchains"), nil)
 }
 
-// List Blockchains
-func ListBlockchainSchemas(chainId string) (blockchainSchema string, errMsg string, status bool) {
-	return listBlockchainInfo(chainId, "schemas")
+// List Blockchain schemas
+func ListBlockchainSchemas(chainId string) (blockchainSchemas []Schema, errMsg string, status bool) {
+	return executeRequest[[]Schema](EndpointBlockchainSchemas, blockchainEndpoint(chainId, "schemas"), nil)
 }
 
 // List Blockchain Information
-func ListBlockchainInformation(chainId string) (blockchainInformation string, errMsg string, status bool) {
-	return listBlockchainInfo(chainId, "meta")
+func ListBlockchainInformation(chainId string) (blockchainInformation BlockchainInfo, errMsg string, status bool) {
+	return executeRequest[BlockchainInfo](EndpointBlockchainInformation, blockchainEndpoint(chainId, "meta"), nil)
 }
 
-func listBlockchainInfo(chainId, infoType string) (blockchainInformation string, errMsg string, status bool) {
-	discoverBlockchainsEndpoint := helpers.GetDiscoverEndpoint("blockchains")
+func blockchainEndpoint(chainId, infoType string) string {
+	segments := []string{helpers.GetDiscoverEndpoint("blockchains"), url.PathEscape(chainId), infoType}
+	return strings.Join(segments, "/")
+}
 
-	if chainId == "" {
-		return executeRequest(discoverBlockchainsEndpoint)
+// List views
+// owned is AllViews, OwnedViews or SharedViews, views owned by other users
+// Both parameters are optional
+func ListViews(name string, owned ViewOwnership) (views []View, errMsg string, status bool) {
+	query := url.Values{}
+	if name != "" {
+		query.Set("name", name)
 	}
 
-	segments := []string{discoverBlockchainsEndpoint, chainId, infoType}
-	tokenEndPoint := strings.Join(segments, "/")
+	if owned != AllViews {
+		query.Set("owned", string(owned))
+	}
 
-	return executeRequest(tokenEndPoint)
+	return executeRequest[[]View](EndpointViews, helpers.GetDiscoverEndpoint("views"), query)
 }
 
-// List views
-// owned values can be a "", 'true', 'false'. All string not boolean
-// Both parameters are optional
-func ListViews(name, owned string) (views string, errMsg string, status bool) {
-	tokenEndPoint := helpers.GetDiscoverEndpoint("views") + "?"
-	entryExists := false
+// Master function. Responses come from the cache when UseCache turned it on
+func executeRequest[T any](name Endpoint, endpoint string, query url.Values) (output T, errMsg string, status bool) {
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
 
-	if name != "" {
-		tokenEndPoint += "name=" + name
-		entryExists = true
+	accessToken := os.Getenv("accessToken")
+	fetch := func() ([]byte, string, bool) {
+		return get(endpoint, accessToken)
 	}
 
-	if owned != "" {
-		if entryExists {
-			tokenEndPoint += "&"
-		}
-		tokenEndPoint += "owned=" + owned
+	cacheMutex.RLock()
+	c := cache
+	cacheMutex.RUnlock()
+
+	var body []byte
+	if c != nil {
+		// Users see different resources, so responses are cached per access token
+		body, errMsg, status = c.get(name, accessToken+" "+endpoint, query, fetch)
+	} else {
+		body, errMsg, status = fetch()
+	}
+	if !status {
+		return output, errMsg, false
+	}
+
+	if err := json.Unmarshal(body, &output); err != nil {
+		return output, "unexpected discovery response: " + err.Error(), false
 	}
 
-	return executeRequest(tokenEndPoint)
+	return output, "", true
 }
 
-// Master function
-func executeRequest(endpoint string) (output string, errMsg string, status bool) {
+// GET a discovery endpoint
+func get(endpoint, accessToken string) (body []byte, errMsg string, status bool) {
 	client := http.Client{}
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
-		return "", err.Error(), false
+		return nil, err.Error(), false
 	}
 
-	bearerToken := fmt.Sprintf("Bearer %s", os.Getenv("accessToken"))
+	bearerToken := fmt.Sprintf("Bearer %s", accessToken)
 	req.Header.Add("Authorization", bearerToken)
 
 	res, err := client.Do(req)
 	if err != nil {
-		return "", err.Error(), false
+		return nil, err.Error(), false
 	}
 
 	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
+	body, err = io.ReadAll(res.Body)
 	if err != nil {
-		return "", err.Error(), false
+		return nil, err.Error(), false
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Sprintf("discovery returned %d: %s", res.StatusCode, body), false
 	}
 
-	return string(body), "", true
+	return body, "", true
 }
@@ -0,0 +1,42 @@
+package discovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListTables(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		query = request.URL.RawQuery
+		if request.URL.Query().Get("schema") == "MISSING" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+			return
+		}
+		w.Write([]byte(`[{"schema": "ETH", "table": "BLOCKS"}]`))
+	}))
+	defer server.Close()
+	t.Setenv("BASEURL_GENERAL", server.URL)
+	t.Setenv("BASEURL_DISCOVERY", server.URL)
+
+	tables, errMsg, status := ListTables("ETH", ScopeAll, "BLOCK%&x")
+	if !status {
+		t.Fatal(errMsg)
+	}
+	if len(tables) != 1 || tables[0] != (Table{Schema: "ETH", Table: "BLOCKS"}) {
+		t.Errorf("unexpected tables %+v", tables)
+	}
+	if query != "schema=ETH&scope=ALL&searchPattern=BLOCK%25%26x" {
+		t.Errorf("unexpected query %s", query)
+	}
+
+	if _, errMsg, status := ListTables("MISSING", ScopeAll, ""); status || errMsg != "discovery returned 404: not found" {
+		t.Errorf("expected a 404 error, got %q", errMsg)
+	}
+
+	if _, _, status := ListTables("eth", ScopeAll, ""); status {
+		t.Error("expected a lower case schema to be rejected")
+	}
+}
@@ -0,0 +1,115 @@
+package discovery
+
+// Which resources ListSchemas, ListTables and ListTableRelations return
+type Scope string
+
+const (
+	// All resources
+	ScopeAll Scope = "ALL"
+
+	// Non permissioned resources
+	ScopePublic Scope = "PUBLIC"
+
+	// Resources created by the requesting user
+	ScopePrivate Scope = "PRIVATE"
+
+	// All resources the requesting user can see
+	ScopeSubscription Scope = "SUBSCRIPTION"
+)
+
+// Which views ListViews returns
+type ViewOwnership string
+
+const (
+	AllViews    ViewOwnership = ""
+	OwnedViews  ViewOwnership = "true"
+	SharedViews ViewOwnership = "false"
+)
+
+type Schema struct {
+	Schema string `json:"schema"`
+}
+
+type Table struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+}
+
+type Column struct {
+	Schema   string `json:"schema"`
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	DataType string `json:"dataType"`
+
+	// Precision and Scale are set for decimals
+	Precision int  `json:"precision"`
+	Scale     int  `json:"scale"`
+	Nullable  bool `json:"nullable"`
+
+	// Ordinal is the position of the column in the table, from 1
+	Ordinal int `json:"ordinal"`
+}
+
+// A column of an index. Indexes on several columns have one entry per column
+type Index struct {
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Name    string `json:"name"`
+	Column  string `json:"column"`
+	Unique  bool   `json:"unique"`
+	Ordinal int    `json:"ordinal"`
+}
+
+// A column of a primary key
+type PrimaryKey struct {
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Column  string `json:"column"`
+	Ordinal int    `json:"ordinal"`
+}
+
+// A foreign key link between two tables of a schema
+type Relation struct {
+	PrimaryKeySchema string `json:"primaryKeySchema"`
+	PrimaryKeyTable  string `json:"primaryKeyTable"`
+	PrimaryKeyColumn string `json:"primaryKeyColumn"`
+	ForeignKeySchema string `json:"foreignKeySchema"`
+	ForeignKeyTable  string `json:"foreignKeyTable"`
+	ForeignKeyColumn string `json:"foreignKeyColumn"`
+}
+
+// A foreign key column and the primary key column it references
+type KeyReference struct {
+	PrimaryKeySchema string `json:"primaryKeySchema"`
+	PrimaryKeyTable  string `json:"primaryKeyTable"`
+	PrimaryKeyColumn string `json:"primaryKeyColumn"`
+	ForeignKeySchema string `json:"foreignKeySchema"`
+	ForeignKeyTable  string `json:"foreignKeyTable"`
+	ForeignKeyColumn string `json:"foreignKeyColumn"`
+}
+
+type Blockchain struct {
+	ChainId     string `json:"chainId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// A parameter of a parameterized view, written :NAME in the view SQL
+type ViewParameter struct {
+	Name string `json:"name"`
+
+	// Type is the SQL type, e.g. BIGINT or VARCHAR
+	Type string `json:"type"`
+}
+
+type View struct {
+	// Name is SCHEMA.VIEW
+	Name        string          `json:"viewName"`
+	SQL         string          `json:"viewText"`
+	Description string          `json:"description"`
+	Owner       string          `json:"owner"`
+	Parameters  []ViewParameter `json:"parameters"`
+}
+
+// Metadata of a blockchain. Fields vary by chain
+type BlockchainInfo map[string]interface{}
@@ -23,6 +23,16 @@ func CheckUpperCase(input string) (msg string, status bool){
 }
 
 
+// Performs a regex check to validate if the input is a single name as stored, upper case unless it was created quoted
+func CheckStoredName(input string) (msg string, status bool){
+	m, _ := regexp.Match("^[A-Za-z_][A-Za-z0-9_]*$", []byte(input))
+	if !m {
+		return "invalid name " + input, m
+	}
+
+	return "ok", true
+}
+
 // Performs a regex check to validate if the resourceID is upper case only with numbers
 func CheckUpperCaseResource(input string) (msg string, status bool){
 	parts := strings.Split(input, ".")
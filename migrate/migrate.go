@@ -0,0 +1,418 @@
+// Package migrate applies versioned schema migrations to Space and Time and records them in a tracking table.
+//
+//	migrations, err := migrate.LoadDir("migrations")
+//	migrator, err := migrate.New(client, "ETH.SCHEMA_MIGRATIONS", migrations)
+//	applied, err := migrator.Up(ctx)
+//
+// Statements run through the sqlcore.Client, so a client with a MintKey mints the biscuit each statement needs.
+// The gateway has no transactions: a migration failing halfway is not recorded and its applied statements stay applied and must be reverted by hand
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+var (
+	// Returned when applied migrations changed since they were applied
+	ErrChecksumDrift = errors.New("migration changed after it was applied")
+
+	// Returned when reverting a migration without Down script or function
+	ErrIrreversible = errors.New("migration has no down")
+
+	// Returned by To for a version that is not a known migration
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+// Runs migrations and keeps their history in a tracking table
+type Migrator struct {
+	client     *sqlcore.Client
+	table      string
+	migrations []Migration
+
+	// TableOptions of the tracking table when it is created. By default the table is
+	// permissioned and owned by the client MintKey
+	TableOptions sqlcore.TableOptions
+}
+
+// State of a migration
+type Status struct {
+	Version int64
+	Name    string
+
+	Applied   bool
+	AppliedAt time.Time
+
+	// Drifted migrations were changed after they were applied
+	Drifted bool
+
+	// Missing migrations were applied but are no longer known
+	Missing bool
+}
+
+// A row of the tracking table
+type record struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Reports if discovery lists a SCHEMA.TABLE. Replaced in tests
+var trackingTableExists = func(table string) (bool, error) {
+	schema, name, _ := strings.Cut(table, ".")
+	tables, errMsg, status := discovery.ListTables(schema, discovery.ScopeAll, "")
+	if !status {
+		return false, errors.New("unable to discover the tables of " + schema + ": " + errMsg)
+	}
+
+	for _, candidate := range tables {
+		if candidate.Table == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\.[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Migrator recording applied migrations in table, a SCHEMA.TABLE created on first use
+func New(client *sqlcore.Client, table string, migrations []Migration) (*Migrator, error) {
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid tracking table %q", table)
+	}
+
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for idx, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration %s has version %d, versions start at 1", migration.Name, migration.Version)
+		}
+
+		if idx > 0 && sorted[idx-1].Version == migration.Version {
+			return nil, fmt.Errorf("migration version %d is used twice", migration.Version)
+		}
+
+		if migration.Up == "" && migration.UpFunc == nil {
+			return nil, fmt.Errorf("migration %d has no up", migration.Version)
+		}
+	}
+
+	return &Migrator{client: client, table: strings.ToUpper(table), migrations: sorted}, nil
+}
+
+// State of every known or applied migration, by version. Nothing is applied while the tracking table doesn't exist
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	return m.status(ctx, false)
+}
+
+// Status, creating the tracking table when it doesn't exist and create is set
+func (m *Migrator) status(ctx context.Context, create bool) ([]Status, error) {
+	records, err := m.records(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := records[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.appliedAt
+			status.Drifted = record.checksum != migration.Checksum()
+			delete(records, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, record := range records {
+		statuses = append(statuses, Status{Version: record.version, Name: record.name, Applied: true, AppliedAt: record.appliedAt, Missing: true})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Apply every pending migration in version order
+func (m *Migrator) Up(ctx context.Context) (applied []Migration, err error) {
+	statuses, err := m.checkedStatus(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.applyPending(ctx, statuses, m.latest())
+}
+
+// Revert the last applied migration. Nothing is reverted when no migration was applied
+func (m *Migrator) Down(ctx context.Context) (reverted []Migration, err error) {
+	statuses, err := m.checkedStatus(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	last := lastApplied(statuses)
+	if last == nil {
+		return nil, nil
+	}
+
+	migration, err := m.known(*last)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.revert(ctx, migration); err != nil {
+		return nil, err
+	}
+
+	return []Migration{migration}, nil
+}
+
+// Revert and apply again the last applied migration
+func (m *Migrator) Redo(ctx context.Context) (migration *Migration, err error) {
+	reverted, err := m.Down(ctx)
+	if err != nil || len(reverted) == 0 {
+		return nil, err
+	}
+
+	if err := m.apply(ctx, reverted[0]); err != nil {
+		return nil, err
+	}
+
+	return &reverted[0], nil
+}
+
+// Migrate to a version: pending migrations up to it are applied in order and applied migrations
+// after it are reverted in reverse order. Version 0 reverts every migration
+func (m *Migrator) To(ctx context.Context, version int64) (changed []Migration, err error) {
+	if version != 0 {
+		if _, ok := m.find(version); !ok {
+			return nil, fmt.Errorf("%w %d", ErrUnknownVersion, version)
+		}
+	}
+
+	statuses, err := m.checkedStatus(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revert newest first, check every migration can be reverted before starting
+	var reverts []Migration
+	for idx := len(statuses) - 1; idx >= 0; idx-- {
+		status := statuses[idx]
+		if status.Applied && status.Version > version {
+			migration, err := m.known(status)
+			if err != nil {
+				return nil, err
+			}
+			if !migration.reversible() {
+				return nil, fmt.Errorf("migration %d: %w", migration.Version, ErrIrreversible)
+			}
+			reverts = append(reverts, migration)
+		}
+	}
+
+	for _, migration := range reverts {
+		if err := m.revert(ctx, migration); err != nil {
+			return changed, err
+		}
+		changed = append(changed, migration)
+	}
+
+	applied, err := m.applyPending(ctx, statuses, version)
+	return append(changed, applied...), err
+}
+
+// Apply pending migrations up to a version, in order
+func (m *Migrator) applyPending(ctx context.Context, statuses []Status, version int64) (applied []Migration, err error) {
+	for _, status := range statuses {
+		if !status.Applied && status.Version <= version {
+			migration, _ := m.find(status.Version)
+			if err := m.apply(ctx, migration); err != nil {
+				return applied, err
+			}
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// Status, failing on drifted migrations so nothing runs on top of a changed history
+func (m *Migrator) checkedStatus(ctx context.Context, create bool) ([]Status, error) {
+	statuses, err := m.status(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []string
+	for _, status := range statuses {
+		if status.Drifted {
+			drifted = append(drifted, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+
+	if len(drifted) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrChecksumDrift, strings.Join(drifted, ", "))
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	var err error
+	if migration.UpFunc != nil {
+		err = migration.UpFunc(ctx, m.client)
+	} else {
+		_, err = m.client.ExecScript(ctx, migration.Up, sqlcore.ScriptOptions{})
+	}
+
+	if err != nil {
+		return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (VERSION, NAME, CHECKSUM, APPLIED_AT) VALUES (?, ?, ?, ?)", m.table)
+	if err := m.client.DMLArgs(ctx, insert, []string{m.table}, migration.Version, migration.Name, migration.Checksum(), time.Now().UTC()); err != nil {
+		return fmt.Errorf("migration %d_%s was applied but not recorded: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) revert(ctx context.Context, migration Migration) error {
+	var err error
+	switch {
+	case migration.DownFunc != nil:
+		err = migration.DownFunc(ctx, m.client)
+	case migration.Down != "":
+		_, err = m.client.ExecScript(ctx, migration.Down, sqlcore.ScriptOptions{})
+	default:
+		err = ErrIrreversible
+	}
+
+	if err != nil {
+		return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+	}
+
+	remove := fmt.Sprintf("DELETE FROM %s WHERE VERSION = ?", m.table)
+	if err := m.client.DMLArgs(ctx, remove, []string{m.table}, migration.Version); err != nil {
+		return fmt.Errorf("migration %d_%s was reverted but is still recorded: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+// Applied migrations by version. When the tracking table can't be read and discovery doesn't list it,
+// there are none and the table is created if create is set. Other read errors are returned
+func (m *Migrator) records(ctx context.Context, create bool) (map[int64]record, error) {
+	query := fmt.Sprintf("SELECT VERSION, NAME, CHECKSUM, APPLIED_AT FROM %s", m.table)
+
+	resultSet, err := m.client.Query(ctx, query, []string{m.table})
+	var gatewayErr *sqlcore.GatewayError
+	if errors.As(err, &gatewayErr) {
+		exists, existsErr := trackingTableExists(m.table)
+		if existsErr != nil || exists {
+			return nil, fmt.Errorf("read %s: %w", m.table, err)
+		}
+
+		if create {
+			if createErr := m.createTable(ctx); createErr != nil {
+				return nil, fmt.Errorf("read %s: %v, create it: %w", m.table, err, createErr)
+			}
+		}
+		return map[int64]record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := map[int64]record{}
+	for _, row := range resultSet.Rows {
+		var r record
+		if r.version, err = row.Int64("VERSION"); err != nil {
+			return nil, err
+		}
+		if r.name, err = row.String("NAME"); err != nil {
+			return nil, err
+		}
+		if r.checksum, err = row.String("CHECKSUM"); err != nil {
+			return nil, err
+		}
+		if r.appliedAt, err = row.Time("APPLIED_AT"); err != nil {
+			return nil, err
+		}
+		records[r.version] = r
+	}
+
+	return records, nil
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	options := m.TableOptions
+	if options.AccessType == "" {
+		options.AccessType = sqlcore.AccessPermissioned
+	}
+	if options.PublicKey == nil && options.OwnerKey == nil {
+		if m.client.MintKey == nil {
+			return errors.New("the tracking table needs TableOptions or a client MintKey")
+		}
+		options.OwnerKey = m.client.MintKey
+	}
+
+	create := fmt.Sprintf("CREATE TABLE %s (VERSION BIGINT PRIMARY KEY, NAME VARCHAR, CHECKSUM VARCHAR, APPLIED_AT TIMESTAMP)", m.table)
+	_, err := m.client.CreateTable(ctx, create, options)
+	return err
+}
+
+// Version of the last known migration
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// Known migration of an applied status. Missing migrations can't be reverted as their down is unknown
+func (m *Migrator) known(status Status) (Migration, error) {
+	migration, ok := m.find(status.Version)
+	if !ok {
+		return Migration{}, fmt.Errorf("applied migration %d_%s is missing, its down is unknown", status.Version, status.Name)
+	}
+
+	return migration, nil
+}
+
+func lastApplied(statuses []Status) *Status {
+	for idx := len(statuses) - 1; idx >= 0; idx-- {
+		if statuses[idx].Applied {
+			return &statuses[idx]
+		}
+	}
+
+	return nil
+}
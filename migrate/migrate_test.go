@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+var (
+	insertPattern = regexp.MustCompile(`^INSERT INTO MIG\.VERSIONS .* VALUES \((\d+), '([^']*)', '([^']*)', TIMESTAMP '([^']*)'\)$`)
+	deletePattern = regexp.MustCompile(`^DELETE FROM MIG\.VERSIONS WHERE VERSION = (\d+)$`)
+)
+
+// Keeps the tracking table in memory and records the other statements
+type trackingGateway struct {
+	created bool
+
+	// readStatus fails reads of an existing tracking table with this status when set
+	readStatus int
+
+	records    map[string][]string
+	statements []string
+}
+
+func (g *trackingGateway) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body struct {
+		SqlText string `json:"sqlText"`
+	}
+	json.NewDecoder(request.Body).Decode(&body)
+
+	status, response := 200, "[]"
+	switch {
+	case strings.HasPrefix(body.SqlText, "SELECT VERSION"):
+		if !g.created {
+			status = 400
+			break
+		}
+		if g.readStatus != 0 {
+			status = g.readStatus
+			break
+		}
+
+		var rows []string
+		for version, record := range g.records {
+			rows = append(rows, fmt.Sprintf(`{"VERSION": %s, "NAME": %q, "CHECKSUM": %q, "APPLIED_AT": %q}`, version, record[0], record[1], record[2]))
+		}
+		response = "[" + strings.Join(rows, ",") + "]"
+
+	case strings.HasPrefix(body.SqlText, "CREATE TABLE MIG.VERSIONS"):
+		g.created = true
+
+	case insertPattern.MatchString(body.SqlText):
+		match := insertPattern.FindStringSubmatch(body.SqlText)
+		g.records[match[1]] = match[2:]
+
+	case deletePattern.MatchString(body.SqlText):
+		delete(g.records, deletePattern.FindStringSubmatch(body.SqlText)[1])
+
+	default:
+		g.statements = append(g.statements, body.SqlText)
+	}
+
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(response)), Header: http.Header{}}, nil
+}
+
+func testMigrator(t *testing.T, migrations []Migration) (*Migrator, *trackingGateway) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+
+	_, privateKey, _ := ed25519.GenerateKey(nil)
+	gateway := &trackingGateway{records: map[string][]string{}}
+	client := sqlcore.NewClient("test")
+	client.HTTPClient = &http.Client{Transport: gateway}
+	client.MintKey = privateKey
+
+	migrator, err := New(client, "MIG.VERSIONS", migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := trackingTableExists
+	t.Cleanup(func() { trackingTableExists = previous })
+	trackingTableExists = func(table string) (bool, error) {
+		return gateway.created, nil
+	}
+
+	return migrator, gateway
+}
+
+func TestLoadFS(t *testing.T) {
+	files := fstest.MapFS{
+		"sql/0002_add_name.up.sql":    {Data: []byte("ALTER TABLE ETH.USERS ADD NAME VARCHAR")},
+		"sql/0002_add_name.down.sql":  {Data: []byte("ALTER TABLE ETH.USERS DROP COLUMN NAME")},
+		"sql/0001_users.up.sql":       {Data: []byte("CREATE TABLE ETH.USERS (ID BIGINT PRIMARY KEY)")},
+		"sql/README.md":               {Data: []byte("ignored")},
+		"sql/0003_orphan.down.sql":    {Data: []byte("DROP TABLE ETH.ORPHAN")},
+		"broken/0001_a.up.sql":        {Data: []byte("SELECT 1")},
+		"broken/0001_b.down.sql":      {Data: []byte("SELECT 1")},
+		"other/0001_users.up.sql":     {Data: []byte("CREATE TABLE ETH.USERS (ID BIGINT PRIMARY KEY)")},
+		"other/0002_add_name.up.sql":  {Data: []byte("ALTER TABLE ETH.USERS ADD NAME VARCHAR")},
+		"other/0002_add_name.txt.sql": {Data: []byte("ignored")},
+	}
+
+	if _, err := LoadFS(files, "sql"); err == nil {
+		t.Error("expected a down file without up file to be rejected")
+	}
+
+	if _, err := LoadFS(files, "broken"); err == nil {
+		t.Error("expected a version with two names to be rejected")
+	}
+
+	migrations, err := LoadFS(files, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Name != "users" || migrations[1].Version != 2 || migrations[1].Down != "" {
+		t.Errorf("unexpected migrations %+v", migrations)
+	}
+
+	capabilities, err := migrations[1].Capabilities()
+	if err != nil || len(capabilities) != 1 || capabilities[0].Operation != "ddl_alter" || capabilities[0].Resource != "eth.users" {
+		t.Errorf("unexpected capabilities %v: %v", capabilities, err)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	migrations := []Migration{
+		{Version: 2, Name: "add_name", Up: "ALTER TABLE ETH.USERS ADD NAME VARCHAR", Down: "ALTER TABLE ETH.USERS DROP COLUMN NAME"},
+		{Version: 1, Name: "users", Up: "CREATE TABLE ETH.USERS (ID BIGINT PRIMARY KEY)", Down: "DROP TABLE ETH.USERS"},
+		{Version: 3, Name: "seed", UpFunc: func(ctx context.Context, client *sqlcore.Client) error {
+			return client.DML(ctx, "INSERT INTO ETH.USERS (ID) VALUES (1)", nil)
+		}},
+	}
+	migrator, gateway := testMigrator(t, migrations)
+
+	// Status doesn't create the tracking table
+	statuses, err := migrator.Status(ctx)
+	if err != nil || len(statuses) != 3 || statuses[0].Applied || gateway.created {
+		t.Fatalf("expected 3 pending migrations and no tracking table, got %+v: %v", statuses, err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != 3 || applied[0].Version != 1 {
+		t.Fatalf("expected 3 migrations applied in order, got %v: %v", applied, err)
+	}
+
+	if !gateway.created || len(gateway.records) != 3 || len(gateway.statements) != 3 {
+		t.Errorf("unexpected gateway state %+v", gateway)
+	}
+
+	statuses, _ = migrator.Status(ctx)
+	for _, status := range statuses {
+		if !status.Applied || status.Drifted || status.AppliedAt.IsZero() {
+			t.Errorf("unexpected status %+v", status)
+		}
+	}
+
+	if _, err := migrator.Down(ctx); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("expected the Go migration to be irreversible, got %v", err)
+	}
+
+	if _, err := migrator.To(ctx, 7); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("expected an unknown version error, got %v", err)
+	}
+
+	// Drop the seed record, as if it was never applied, and revert to version 1
+	delete(gateway.records, "3")
+	changed, err := migrator.To(ctx, 1)
+	if err != nil || len(changed) != 1 || changed[0].Version != 2 || gateway.statements[3] != "ALTER TABLE ETH.USERS DROP COLUMN NAME" {
+		t.Errorf("expected migration 2 to be reverted, got %v: %v", changed, err)
+	}
+
+	redone, err := migrator.Redo(ctx)
+	if err != nil || redone.Version != 1 || gateway.statements[4] != "DROP TABLE ETH.USERS" || len(gateway.records) != 1 {
+		t.Errorf("expected migration 1 to be redone, got %v: %v", redone, err)
+	}
+
+	// Changing an applied migration stops every command
+	migrations[1].Up = "CREATE TABLE ETH.USERS (ID BIGINT PRIMARY KEY, EMAIL VARCHAR)"
+	drifted, _ := testMigrator(t, migrations)
+	drifted.client.HTTPClient = &http.Client{Transport: gateway}
+	if _, err := drifted.Up(ctx); !errors.Is(err, ErrChecksumDrift) {
+		t.Errorf("expected checksum drift, got %v", err)
+	}
+
+	versions := make([]string, 0, len(gateway.records))
+	for version := range gateway.records {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	if strings.Join(versions, ",") != "1" {
+		t.Errorf("unexpected records %v", versions)
+	}
+}
+
+func TestMigratorReadError(t *testing.T) {
+	migrator, gateway := testMigrator(t, []Migration{{Version: 1, Name: "users", Up: "CREATE TABLE ETH.USERS (ID BIGINT PRIMARY KEY)"}})
+	gateway.created = true
+	gateway.readStatus = 401
+
+	var gatewayErr *sqlcore.GatewayError
+	if _, err := migrator.Up(context.Background()); !errors.As(err, &gatewayErr) || gatewayErr.StatusCode != 401 {
+		t.Errorf("expected the read error, got %v", err)
+	}
+
+	if len(gateway.statements) != 0 {
+		t.Errorf("expected nothing to run, got %q", gateway.statements)
+	}
+}
@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// A schema change, written as SQL scripts or Go functions
+type Migration struct {
+	Version int64
+	Name    string
+
+	// Up and Down are SQL scripts run statement by statement. Down is optional
+	Up   string
+	Down string
+
+	// UpFunc and DownFunc replace the scripts for changes that need code
+	UpFunc   func(ctx context.Context, client *sqlcore.Client) error
+	DownFunc func(ctx context.Context, client *sqlcore.Client) error
+}
+
+// Checksum of the migration scripts, recorded when it is applied. Go migrations are only checked by name
+func (m Migration) Checksum() string {
+	sum := sha256.New()
+	if m.UpFunc != nil || m.DownFunc != nil {
+		sum.Write([]byte("func\x00" + m.Name))
+	} else {
+		sum.Write([]byte(m.Up + "\x00" + m.Down))
+	}
+
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+func (m Migration) reversible() bool {
+	return m.Down != "" || m.DownFunc != nil
+}
+
+// Biscuit capabilities the SQL scripts of the migration need, for clients using registered biscuits.
+// Clients with a MintKey mint them statement by statement
+func (m Migration) Capabilities() ([]authorization.SxTBiscuitStruct, error) {
+	var capabilities []authorization.SxTBiscuitStruct
+	seen := map[authorization.SxTBiscuitStruct]bool{}
+
+	for _, script := range []string{m.Up, m.Down} {
+		statements, err := sqlparser.Split(script)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d: %w", m.Version, err)
+		}
+
+		for _, statement := range statements {
+			analyzed, err := sqlparser.Analyze(statement.SQL)
+			if err != nil {
+				return nil, fmt.Errorf("migration %d at %s: %w", m.Version, statement.Pos, err)
+			}
+
+			for _, capability := range analyzed.Capabilities() {
+				if !seen[capability] {
+					seen[capability] = true
+					capabilities = append(capabilities, capability)
+				}
+			}
+		}
+	}
+
+	return capabilities, nil
+}
+
+// <version>_<name>.up.sql and <version>_<name>.down.sql
+var fileNamePattern = regexp.MustCompile(`^([0-9]+)_([A-Za-z0-9_\-]+)\.(up|down)\.sql$`)
+
+// Read migrations from a directory of <version>_<name>.up.sql and <version>_<name>.down.sql files
+func LoadDir(dir string) ([]Migration, error) {
+	return LoadFS(os.DirFS(dir), ".")
+}
+
+// Read migration files from a directory of a file system, e.g. an embed.FS. Other files are ignored
+func LoadFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,228 @@
+// Package sqlbuilder renders SxT SQL statements from Go calls.
+//
+//	query, err := sqlbuilder.Select("ID", "TEST").From("ETH.T").Where(sqlbuilder.Eq("ID", 5)).Limit(10).Build()
+//	data, err := client.DQL(ctx, query.SQL, query.Resources)
+//
+// Values are always rendered as escaped literals by sqlcore.FormatLiteral. The resources and biscuit
+// capabilities of a built statement come from the same analysis sqlcore uses, so they can't drift apart
+package sqlbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Implemented by every statement builder
+type Builder interface {
+	Build() (*Query, error)
+}
+
+// A rendered statement with what it needs to run
+type Query struct {
+	SQL string
+
+	// Kind is sqlparser.KindDDL, KindDML or KindDQL
+	Kind string
+
+	// Resources for the resources argument of sqlcore calls, the target table first
+	Resources []string
+
+	// Capabilities a biscuit needs to run the statement
+	Capabilities []authorization.SxTBiscuitStruct
+}
+
+// Run a built query. With client.Encryption, values compared to encrypted columns are encrypted
+func QueryRows(ctx context.Context, client *sqlcore.Client, builder Builder) (*sqlcore.ResultSet, error) {
+	query, err := build(ctx, client, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Kind != sqlparser.KindDQL {
+		return nil, fmt.Errorf("%s is not a query", query.SQL)
+	}
+
+	return client.Query(ctx, query.SQL, query.Resources)
+}
+
+// Run a built INSERT, UPDATE, DELETE or MERGE. With client.Encryption, values of encrypted columns are encrypted
+func Exec(ctx context.Context, client *sqlcore.Client, builder Builder) error {
+	query, err := build(ctx, client, builder)
+	if err != nil {
+		return err
+	}
+
+	if query.Kind != sqlparser.KindDML {
+		return fmt.Errorf("%s is not a DML statement", query.SQL)
+	}
+
+	return client.DML(ctx, query.SQL, query.Resources)
+}
+
+// Analyze rendered SQL into a Query
+func newQuery(sqlText string) (*Query, error) {
+	statement, err := sqlparser.Analyze(sqlText)
+	if err != nil {
+		return nil, fmt.Errorf("rendered invalid SQL %q: %w", sqlText, err)
+	}
+
+	return &Query{
+		SQL:          sqlText,
+		Kind:         statement.Kind,
+		Resources:    statement.Resources(),
+		Capabilities: statement.Capabilities(),
+	}, nil
+}
+
+// A column reference used where a value is expected, e.g. Eq("TGT.ID", Col("SRC.ID"))
+type Column string
+
+func Col(name string) Column {
+	return Column(name)
+}
+
+// Render a value: column references and subqueries as SQL, anything else as a literal
+func renderValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case Column:
+		return identifierPath(string(v), false)
+	case *SelectBuilder:
+		query, err := v.render()
+		if err != nil {
+			return "", err
+		}
+		return "(" + query + ")", nil
+	}
+
+	return sqlcore.FormatLiteral(value)
+}
+
+func renderValues(values []interface{}) (string, error) {
+	rendered := make([]string, len(values))
+	for idx, value := range values {
+		text, err := renderValue(value)
+		if err != nil {
+			return "", err
+		}
+		rendered[idx] = text
+	}
+
+	return strings.Join(rendered, ", "), nil
+}
+
+// Check a dotted name such as SCHEMA.TABLE, T.COL or "Quoted".COL, and T.* when star is allowed
+func identifierPath(name string, star bool) (string, error) {
+	tokens, err := sqlparser.Tokenize(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid name %q", name)
+	}
+
+	// The last token is EOF
+	tokens = tokens[:len(tokens)-1]
+	if len(tokens)%2 == 0 {
+		return "", fmt.Errorf("invalid name %q", name)
+	}
+
+	for idx, token := range tokens {
+		switch {
+		case idx%2 == 1:
+			if token.Type != sqlparser.Punct || token.Text != "." {
+				return "", fmt.Errorf("invalid name %q", name)
+			}
+		case token.Type == sqlparser.Ident || token.Type == sqlparser.QuotedIdent:
+		case star && idx == len(tokens)-1 && token.Text == "*":
+		default:
+			return "", fmt.Errorf("invalid name %q", name)
+		}
+	}
+
+	return strings.TrimSpace(name), nil
+}
+
+// A table with an optional alias: ETH.T, ETH.T A or ETH.T AS A
+func tableReference(reference string) (string, error) {
+	fields := strings.Fields(reference)
+	switch {
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+		fields = []string{fields[0], fields[2]}
+	case len(fields) != 1 && len(fields) != 2:
+		return "", fmt.Errorf("invalid table %q", reference)
+	}
+
+	for _, field := range fields {
+		if _, err := identifierPath(field, false); err != nil {
+			return "", fmt.Errorf("invalid table %q", reference)
+		}
+	}
+
+	if len(fields) == 2 {
+		if strings.Contains(fields[1], ".") {
+			return "", fmt.Errorf("invalid table alias in %q", reference)
+		}
+		return fields[0] + " AS " + fields[1], nil
+	}
+
+	return fields[0], nil
+}
+
+// Select expressions may be names, aliases, function calls and arithmetic, but no literals or placeholders,
+// which must go through values
+func selectExpression(expression string) (string, error) {
+	tokens, err := sqlparser.Tokenize(expression)
+	if err != nil || len(tokens) < 2 {
+		return "", fmt.Errorf("invalid select expression %q", expression)
+	}
+
+	for _, token := range tokens {
+		if token.Type == sqlparser.String || token.Type == sqlparser.Param || token.Text == ";" {
+			return "", fmt.Errorf("select expression %q can't hold literals, use values", expression)
+		}
+	}
+
+	if strings.Contains(expression, "--") || strings.Contains(expression, "/*") {
+		return "", fmt.Errorf("select expression %q can't hold comments", expression)
+	}
+
+	return strings.TrimSpace(expression), nil
+}
+
+// ORDER BY terms: a name or select position, then ASC or DESC
+func orderTerm(term string) (string, error) {
+	fields := strings.Fields(term)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", fmt.Errorf("invalid order %q", term)
+	}
+
+	if len(fields) == 2 && !strings.EqualFold(fields[1], "ASC") && !strings.EqualFold(fields[1], "DESC") {
+		return "", fmt.Errorf("invalid order %q", term)
+	}
+
+	if _, err := identifierPath(fields[0], false); err != nil && strings.Trim(fields[0], "0123456789") != "" {
+		return "", fmt.Errorf("invalid order %q", term)
+	}
+
+	if len(fields) == 2 {
+		return fields[0] + " " + strings.ToUpper(fields[1]), nil
+	}
+
+	return fields[0], nil
+}
+
+// Records the first error of a chain of builder calls
+type errorHolder struct {
+	err error
+}
+
+func (h *errorHolder) fail(err error) {
+	if h.err == nil && err != nil {
+		h.err = err
+	}
+}
+
+var errNoTable = errors.New("statement has no table")
@@ -0,0 +1,147 @@
+package sqlbuilder
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+func TestBuild(t *testing.T) {
+	cases := []struct {
+		builder      Builder
+		sqlText      string
+		resources    []string
+		capabilities []authorization.SxTBiscuitStruct
+	}{
+		{
+			Select("ID", "TEST").From("ETH.T").Where(Eq("ID", 5), Like("TEST", "it's%")).OrderBy("ID desc").Limit(10),
+			"SELECT ID, TEST FROM ETH.T WHERE (ID = 5) AND (TEST LIKE 'it''s%') ORDER BY ID DESC LIMIT 10",
+			[]string{"ETH.T"},
+			[]authorization.SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.t"}},
+		},
+		{
+			Select("A.ID", "COUNT(*) AS N").From("ETH.T A").Join("ETH.U AS B", Eq("A.ID", Col("B.ID"))).
+				Where(Or(IsNull("B.X"), In("B.Y", 1, 2))).GroupBy("A.ID"),
+			"SELECT A.ID, COUNT(*) AS N FROM ETH.T AS A JOIN ETH.U AS B ON A.ID = B.ID WHERE (B.X IS NULL) OR (B.Y IN (1, 2)) GROUP BY A.ID",
+			[]string{"ETH.T", "ETH.U"},
+			[]authorization.SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.t"}, {Operation: "dql_select", Resource: "eth.u"}},
+		},
+		{
+			InsertInto("ETH.T").Columns("ID", "TEST").Values(1, "a").Values(2, nil),
+			"INSERT INTO ETH.T (ID, TEST) VALUES (1, 'a'), (2, NULL)",
+			[]string{"ETH.T"},
+			[]authorization.SxTBiscuitStruct{{Operation: "dml_insert", Resource: "eth.t"}},
+		},
+		{
+			InsertInto("ETH.T").Columns("ID").Select(Select("ID").From("ETH.U")),
+			"INSERT INTO ETH.T (ID) SELECT ID FROM ETH.U",
+			[]string{"ETH.T", "ETH.U"},
+			[]authorization.SxTBiscuitStruct{{Operation: "dml_insert", Resource: "eth.t"}, {Operation: "dql_select", Resource: "eth.u"}},
+		},
+		{
+			Update("ETH.T").Set("TEST", "b").Where(In("ID", Select("ID").From("ETH.U"))),
+			"UPDATE ETH.T SET TEST = 'b' WHERE ID IN (SELECT ID FROM ETH.U)",
+			[]string{"ETH.T", "ETH.U"},
+			[]authorization.SxTBiscuitStruct{{Operation: "dml_update", Resource: "eth.t"}, {Operation: "dql_select", Resource: "eth.u"}},
+		},
+		{
+			DeleteFrom("ETH.T").Where(Raw("ID > ?", 3)),
+			"DELETE FROM ETH.T WHERE ID > 3",
+			[]string{"ETH.T"},
+			[]authorization.SxTBiscuitStruct{{Operation: "dml_delete", Resource: "eth.t"}},
+		},
+		{
+			MergeInto("ETH.T AS TGT").Using("ETH.S AS SRC").On(Eq("TGT.ID", Col("SRC.ID"))).
+				WhenMatchedSet("TEST", Col("SRC.TEST")).
+				WhenNotMatchedInsert([]string{"ID", "TEST"}, Col("SRC.ID"), Col("SRC.TEST")),
+			"MERGE INTO ETH.T AS TGT USING ETH.S AS SRC ON TGT.ID = SRC.ID WHEN MATCHED THEN UPDATE SET TEST = SRC.TEST " +
+				"WHEN NOT MATCHED THEN INSERT (ID, TEST) VALUES (SRC.ID, SRC.TEST)",
+			[]string{"ETH.T", "ETH.S"},
+			[]authorization.SxTBiscuitStruct{{Operation: "dml_merge", Resource: "eth.t"}, {Operation: "dql_select", Resource: "eth.s"}},
+		},
+	}
+
+	for _, c := range cases {
+		query, err := c.builder.Build()
+		if err != nil {
+			t.Errorf("%s: %v", c.sqlText, err)
+			continue
+		}
+
+		if query.SQL != c.sqlText {
+			t.Errorf("expected %s, got %s", c.sqlText, query.SQL)
+		}
+
+		if !reflect.DeepEqual(query.Resources, c.resources) {
+			t.Errorf("%s: expected resources %v, got %v", c.sqlText, c.resources, query.Resources)
+		}
+
+		if !reflect.DeepEqual(query.Capabilities, c.capabilities) {
+			t.Errorf("%s: expected capabilities %v, got %v", c.sqlText, c.capabilities, query.Capabilities)
+		}
+	}
+}
+
+func TestBuildRejects(t *testing.T) {
+	for _, builder := range []Builder{
+		Select("ID").From("ETH.T; DROP TABLE ETH.T"),
+		Select("'x' AS Y").From("ETH.T"),
+		Select("ID").From("ETH.T").Where(Eq("ID = 1 OR 1", 1)),
+		Select("ID").From("ETH.T").Where(Eq("ID", nil)),
+		Select("ID").From("ETH.T").Where(In("ID")),
+		Select("ID").From("ETH.T").OrderBy("ID; --"),
+		Select("ID"),
+		InsertInto("ETH.T").Columns("ID").Values(1, 2),
+		Update("ETH.T").Set("ID", 1),
+		DeleteFrom("ETH.T"),
+		MergeInto("ETH.T").Using("ETH.S"),
+	} {
+		if query, err := builder.Build(); err == nil {
+			t.Errorf("expected an error, got %s", query.SQL)
+		}
+	}
+}
+
+func TestBuildEncrypted(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := sqlcore.NewStaticKeyProvider([]byte(strings.Repeat("k", 32)))
+	encryption := sqlcore.NewColumnEncryption(provider)
+	wrappedKey, _ := encryption.GenerateDataKey(ctx)
+	encryption.AddTable("ETH.USERS", sqlcore.EncryptedColumn{Name: "EMAIL", Deterministic: true, WrappedKey: wrappedKey}, sqlcore.EncryptedColumn{Name: "SSN"})
+
+	client := sqlcore.NewClient("test")
+	client.Encryption = encryption
+
+	email, _ := encryption.EncryptValue(ctx, "ETH.USERS", "EMAIL", "a@b.c")
+	literal, _ := sqlcore.FormatLiteral(email)
+
+	query, err := build(ctx, client, Select().From("ETH.USERS U").Where(Eq("U.EMAIL", "a@b.c"), Gt("ID", 1)))
+	if err != nil || query.SQL != "SELECT * FROM ETH.USERS AS U WHERE (U.EMAIL = "+literal+") AND (ID > 1)" {
+		t.Errorf("unexpected query %+v: %v", query, err)
+	}
+
+	query, err = build(ctx, client, Update("ETH.USERS").Set("SSN", "123").Where(In("EMAIL", "a@b.c")))
+	if err != nil || strings.Contains(query.SQL, "123") || !strings.HasSuffix(query.SQL, "WHERE EMAIL IN ("+literal+")") {
+		t.Errorf("unexpected update %+v: %v", query, err)
+	}
+
+	query, err = build(ctx, client, InsertInto("ETH.USERS").Columns("ID", "EMAIL").Values(1, "a@b.c"))
+	if err != nil || query.SQL != "INSERT INTO ETH.USERS (ID, EMAIL) VALUES (1, "+literal+")" {
+		t.Errorf("unexpected insert %+v: %v", query, err)
+	}
+
+	for _, builder := range []Builder{
+		Select().From("ETH.USERS").Where(Eq("SSN", "123")),
+		Select().From("ETH.USERS").Where(Lt("EMAIL", "a")),
+		InsertInto("ETH.USERS").Values(1, "a@b.c"),
+		MergeInto("ETH.USERS AS TGT").Using("ETH.STAGING AS SRC").On(Eq("TGT.ID", Col("SRC.ID"))).WhenMatchedDelete(),
+	} {
+		if _, err := build(ctx, client, builder); err == nil {
+			t.Errorf("%T: expected an error", builder)
+		}
+	}
+}
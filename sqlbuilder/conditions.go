@@ -0,0 +1,232 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+// A WHERE, HAVING, JOIN or MERGE condition
+type Condition interface {
+	render() (string, error)
+}
+
+type comparison struct {
+	column   string
+	operator string
+	value    interface{}
+}
+
+func (c comparison) render() (string, error) {
+	column, err := identifierPath(c.column, false)
+	if err != nil {
+		return "", err
+	}
+
+	if c.value == nil && (c.operator == "=" || c.operator == "<>") {
+		return "", fmt.Errorf("%s %s NULL is never true, use IsNull or IsNotNull", c.column, c.operator)
+	}
+
+	value, err := renderValue(c.value)
+	if err != nil {
+		return "", err
+	}
+
+	return column + " " + c.operator + " " + value, nil
+}
+
+// column = value
+func Eq(column string, value interface{}) Condition {
+	return comparison{column: column, operator: "=", value: value}
+}
+
+// column <> value
+func Ne(column string, value interface{}) Condition {
+	return comparison{column: column, operator: "<>", value: value}
+}
+
+// column < value
+func Lt(column string, value interface{}) Condition {
+	return comparison{column: column, operator: "<", value: value}
+}
+
+// column <= value
+func Le(column string, value interface{}) Condition {
+	return comparison{column: column, operator: "<=", value: value}
+}
+
+// column > value
+func Gt(column string, value interface{}) Condition {
+	return comparison{column: column, operator: ">", value: value}
+}
+
+// column >= value
+func Ge(column string, value interface{}) Condition {
+	return comparison{column: column, operator: ">=", value: value}
+}
+
+// column LIKE pattern
+func Like(column string, pattern string) Condition {
+	return comparison{column: column, operator: "LIKE", value: pattern}
+}
+
+type inCondition struct {
+	column string
+	values []interface{}
+	not    bool
+}
+
+func (c inCondition) render() (string, error) {
+	column, err := identifierPath(c.column, false)
+	if err != nil {
+		return "", err
+	}
+
+	if len(c.values) == 0 {
+		return "", fmt.Errorf("%s IN needs values", c.column)
+	}
+
+	values, err := renderValues(c.values)
+	if err != nil {
+		return "", err
+	}
+
+	operator := " IN ("
+	if c.not {
+		operator = " NOT IN ("
+	}
+
+	return column + operator + values + ")", nil
+}
+
+// column IN (values...). A single *SelectBuilder value is a subquery
+func In(column string, values ...interface{}) Condition {
+	if len(values) == 1 {
+		if subquery, ok := values[0].(*SelectBuilder); ok {
+			return subqueryIn{column: column, subquery: subquery}
+		}
+	}
+
+	return inCondition{column: column, values: values}
+}
+
+// column NOT IN (values...)
+func NotIn(column string, values ...interface{}) Condition {
+	return inCondition{column: column, values: values, not: true}
+}
+
+type subqueryIn struct {
+	column   string
+	subquery *SelectBuilder
+}
+
+func (c subqueryIn) render() (string, error) {
+	column, err := identifierPath(c.column, false)
+	if err != nil {
+		return "", err
+	}
+
+	subquery, err := c.subquery.render()
+	if err != nil {
+		return "", err
+	}
+
+	return column + " IN (" + subquery + ")", nil
+}
+
+type nullCondition struct {
+	column string
+	not    bool
+}
+
+func (c nullCondition) render() (string, error) {
+	column, err := identifierPath(c.column, false)
+	if err != nil {
+		return "", err
+	}
+
+	if c.not {
+		return column + " IS NOT NULL", nil
+	}
+
+	return column + " IS NULL", nil
+}
+
+// column IS NULL
+func IsNull(column string) Condition {
+	return nullCondition{column: column}
+}
+
+// column IS NOT NULL
+func IsNotNull(column string) Condition {
+	return nullCondition{column: column, not: true}
+}
+
+type junction struct {
+	operator   string
+	conditions []Condition
+}
+
+func (j junction) render() (string, error) {
+	if len(j.conditions) == 0 {
+		return "", errors.New(j.operator + " without conditions")
+	}
+
+	if len(j.conditions) == 1 {
+		return j.conditions[0].render()
+	}
+
+	parts := make([]string, len(j.conditions))
+	for idx, condition := range j.conditions {
+		part, err := condition.render()
+		if err != nil {
+			return "", err
+		}
+		parts[idx] = "(" + part + ")"
+	}
+
+	return strings.Join(parts, " "+j.operator+" "), nil
+}
+
+// All conditions hold
+func And(conditions ...Condition) Condition {
+	return junction{operator: "AND", conditions: conditions}
+}
+
+// One of the conditions holds
+func Or(conditions ...Condition) Condition {
+	return junction{operator: "OR", conditions: conditions}
+}
+
+type negation struct {
+	condition Condition
+}
+
+func (n negation) render() (string, error) {
+	condition, err := n.condition.render()
+	if err != nil {
+		return "", err
+	}
+
+	return "NOT (" + condition + ")", nil
+}
+
+func Not(condition Condition) Condition {
+	return negation{condition: condition}
+}
+
+type rawCondition struct {
+	sqlText string
+	args    []interface{}
+}
+
+func (r rawCondition) render() (string, error) {
+	return sqlcore.BindParams(r.sqlText, r.args...)
+}
+
+// A condition written in SQL with placeholders bound to args, see sqlcore.BindParams
+func Raw(sqlText string, args ...interface{}) Condition {
+	return rawCondition{sqlText: sqlText, args: args}
+}
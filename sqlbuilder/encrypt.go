@@ -0,0 +1,250 @@
+package sqlbuilder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+// Implemented by builders whose values are encrypted when the client has sqlcore.ColumnEncryption
+type encryptingBuilder interface {
+	buildEncrypted(ctx context.Context, encryption *sqlcore.ColumnEncryption) (*Query, error)
+}
+
+// Build a statement, encrypting the values of encrypted columns when the client encrypts them
+func build(ctx context.Context, client *sqlcore.Client, builder Builder) (*Query, error) {
+	if encrypting, ok := builder.(encryptingBuilder); ok && client.Encryption != nil {
+		return encrypting.buildEncrypted(ctx, client.Encryption)
+	}
+
+	return builder.Build()
+}
+
+// Encrypts values by the table their column belongs to
+type encrypter struct {
+	ctx        context.Context
+	encryption *sqlcore.ColumnEncryption
+
+	// table of unqualified columns, and tables by name and alias for qualified ones
+	table  string
+	tables map[string]string
+}
+
+func newEncrypter(ctx context.Context, encryption *sqlcore.ColumnEncryption, references ...string) *encrypter {
+	e := &encrypter{ctx: ctx, encryption: encryption, tables: map[string]string{}}
+	for idx, reference := range references {
+		fields := strings.Fields(reference)
+		if idx == 0 {
+			e.table = fields[0]
+		}
+
+		e.tables[strings.ToUpper(fields[0])] = fields[0]
+		if dot := strings.LastIndex(fields[0], "."); dot >= 0 {
+			e.tables[strings.ToUpper(fields[0][dot+1:])] = fields[0]
+		}
+		if len(fields) == 3 {
+			e.tables[strings.ToUpper(fields[2])] = fields[0]
+		}
+	}
+
+	return e
+}
+
+// Table and name of a column such as COL, T.COL or SCHEMA.T.COL
+func (e *encrypter) resolve(column string) (table, name string) {
+	dot := strings.LastIndex(column, ".")
+	if dot < 0 {
+		return e.table, column
+	}
+
+	return e.tables[strings.ToUpper(column[:dot])], column[dot+1:]
+}
+
+// Encrypt a value written to a column
+func (e *encrypter) write(column string, value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case Column, *SelectBuilder:
+		return value, nil
+	}
+
+	table, name := e.resolve(column)
+	return e.encryption.EncryptValue(e.ctx, table, name, value)
+}
+
+// Encrypt a value compared to a column. Only deterministic columns can be compared, and only for equality
+func (e *encrypter) compare(column, operator string, value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case Column, *SelectBuilder:
+		return value, nil
+	}
+
+	table, name := e.resolve(column)
+	settings, ok := e.encryption.Column(table, name)
+	if !ok {
+		return value, nil
+	}
+
+	if !settings.Deterministic || (operator != "=" && operator != "<>" && operator != "IN") {
+		return nil, fmt.Errorf("column %s is encrypted, only deterministic columns can be compared with =, <> or IN", column)
+	}
+
+	return e.encryption.EncryptValue(e.ctx, table, name, value)
+}
+
+// Copy of a condition with its values encrypted. Raw conditions are left as written
+func (e *encrypter) condition(condition Condition) (Condition, error) {
+	switch c := condition.(type) {
+	case comparison:
+		value, err := e.compare(c.column, c.operator, c.value)
+		c.value = value
+		return c, err
+
+	case inCondition:
+		values := make([]interface{}, len(c.values))
+		for idx, value := range c.values {
+			var err error
+			if values[idx], err = e.compare(c.column, "IN", value); err != nil {
+				return nil, err
+			}
+		}
+		c.values = values
+		return c, nil
+
+	case junction:
+		conditions, err := e.conditions(c.conditions)
+		c.conditions = conditions
+		return c, err
+
+	case negation:
+		inner, err := e.condition(c.condition)
+		c.condition = inner
+		return c, err
+	}
+
+	return condition, nil
+}
+
+func (e *encrypter) conditions(conditions []Condition) ([]Condition, error) {
+	encrypted := make([]Condition, len(conditions))
+	for idx, condition := range conditions {
+		var err error
+		if encrypted[idx], err = e.condition(condition); err != nil {
+			return nil, err
+		}
+	}
+
+	return encrypted, nil
+}
+
+func (e *encrypter) assignments(assignments []assignment) ([]assignment, error) {
+	encrypted := make([]assignment, len(assignments))
+	for idx, set := range assignments {
+		value, err := e.write(set.column, set.value)
+		if err != nil {
+			return nil, err
+		}
+		encrypted[idx] = assignment{column: set.column, value: value}
+	}
+
+	return encrypted, nil
+}
+
+// Values of encrypted columns are encrypted, which needs the inserted columns
+func (b *InsertBuilder) buildEncrypted(ctx context.Context, encryption *sqlcore.ColumnEncryption) (*Query, error) {
+	if b.err != nil || len(encryption.Columns(b.table)) == 0 {
+		return b.Build()
+	}
+
+	if len(b.columns) == 0 {
+		return nil, fmt.Errorf("%s has encrypted columns, the insert must name its columns", b.table)
+	}
+
+	if b.query != nil {
+		return nil, fmt.Errorf("%s has encrypted columns, values can't be inserted from a query", b.table)
+	}
+
+	e := newEncrypter(ctx, encryption, b.table)
+	encrypted := *b
+	encrypted.rows = make([][]interface{}, len(b.rows))
+	for idx, row := range b.rows {
+		values := make([]interface{}, len(row))
+		for column, value := range row {
+			values[column] = value
+			if column < len(b.columns) {
+				var err error
+				if values[column], err = e.write(b.columns[column], value); err != nil {
+					return nil, fmt.Errorf("row %d: %w", idx, err)
+				}
+			}
+		}
+		encrypted.rows[idx] = values
+	}
+
+	return encrypted.Build()
+}
+
+func (b *UpdateBuilder) buildEncrypted(ctx context.Context, encryption *sqlcore.ColumnEncryption) (*Query, error) {
+	if b.err != nil {
+		return b.Build()
+	}
+
+	e := newEncrypter(ctx, encryption, b.table)
+	encrypted := *b
+
+	var err error
+	if encrypted.sets, err = e.assignments(b.sets); err != nil {
+		return nil, err
+	}
+	if encrypted.where, err = e.conditions(b.where); err != nil {
+		return nil, err
+	}
+
+	return encrypted.Build()
+}
+
+func (b *DeleteBuilder) buildEncrypted(ctx context.Context, encryption *sqlcore.ColumnEncryption) (*Query, error) {
+	if b.err != nil {
+		return b.Build()
+	}
+
+	encrypted := *b
+
+	var err error
+	if encrypted.where, err = newEncrypter(ctx, encryption, b.table).conditions(b.where); err != nil {
+		return nil, err
+	}
+
+	return encrypted.Build()
+}
+
+// WHERE values are encrypted for the columns of the FROM and JOIN tables. Unqualified columns belong to the FROM table
+func (b *SelectBuilder) buildEncrypted(ctx context.Context, encryption *sqlcore.ColumnEncryption) (*Query, error) {
+	if b.err != nil || b.from == "" {
+		return b.Build()
+	}
+
+	references := []string{b.from}
+	for _, join := range b.joins {
+		references = append(references, join.table)
+	}
+
+	encrypted := *b
+
+	var err error
+	if encrypted.where, err = newEncrypter(ctx, encryption, references...).conditions(b.where); err != nil {
+		return nil, err
+	}
+
+	return encrypted.Build()
+}
+
+// MERGE values can come from anywhere, so merges into tables with encrypted columns are rejected. Use Client.Upsert
+func (b *MergeBuilder) buildEncrypted(ctx context.Context, encryption *sqlcore.ColumnEncryption) (*Query, error) {
+	if b.err == nil && len(encryption.Columns(strings.Fields(b.table)[0])) > 0 {
+		return nil, fmt.Errorf("%s has encrypted columns, use Client.Upsert to merge into it", b.table)
+	}
+
+	return b.Build()
+}
@@ -0,0 +1,319 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Builds an INSERT statement
+type InsertBuilder struct {
+	errorHolder
+
+	table   string
+	columns []string
+	rows    [][]interface{}
+	query   *SelectBuilder
+}
+
+// Start an INSERT into a table
+func InsertInto(table string) *InsertBuilder {
+	builder := &InsertBuilder{}
+	builder.table, builder.err = identifierPath(table, false)
+	return builder
+}
+
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	for _, column := range columns {
+		name, err := identifierPath(column, false)
+		b.fail(err)
+		b.columns = append(b.columns, name)
+	}
+	return b
+}
+
+// Add a row. Call once per row for multi-row inserts
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Insert the rows of a query instead of values
+func (b *InsertBuilder) Select(query *SelectBuilder) *InsertBuilder {
+	b.query = query
+	return b
+}
+
+func (b *InsertBuilder) Build() (*Query, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var builder strings.Builder
+	builder.WriteString("INSERT INTO " + b.table)
+	if len(b.columns) > 0 {
+		builder.WriteString(" (" + strings.Join(b.columns, ", ") + ")")
+	}
+
+	switch {
+	case b.query != nil && len(b.rows) > 0:
+		return nil, errors.New("insert has both values and a query")
+
+	case b.query != nil:
+		query, err := b.query.render()
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(" " + query)
+
+	case len(b.rows) == 0:
+		return nil, errors.New("insert has no values")
+
+	default:
+		builder.WriteString(" VALUES ")
+		for idx, row := range b.rows {
+			if len(b.columns) > 0 && len(row) != len(b.columns) {
+				return nil, fmt.Errorf("row %d has %d values for %d columns", idx, len(row), len(b.columns))
+			}
+
+			values, err := renderValues(row)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", idx, err)
+			}
+
+			if idx > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString("(" + values + ")")
+		}
+	}
+
+	return newQuery(builder.String())
+}
+
+type assignment struct {
+	column string
+	value  interface{}
+}
+
+func renderAssignments(assignments []assignment) (string, error) {
+	parts := make([]string, len(assignments))
+	for idx, set := range assignments {
+		value, err := renderValue(set.value)
+		if err != nil {
+			return "", err
+		}
+		parts[idx] = set.column + " = " + value
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+// Builds an UPDATE statement
+type UpdateBuilder struct {
+	errorHolder
+
+	table string
+	sets  []assignment
+	where []Condition
+}
+
+// Start an UPDATE of a table
+func Update(table string) *UpdateBuilder {
+	builder := &UpdateBuilder{}
+	builder.table, builder.err = identifierPath(table, false)
+	return builder
+}
+
+// Set a column to a value or, with Col, to another column
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	name, err := identifierPath(column, false)
+	b.fail(err)
+	b.sets = append(b.sets, assignment{column: name, value: value})
+	return b
+}
+
+func (b *UpdateBuilder) Where(conditions ...Condition) *UpdateBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Updates without Where are rejected to avoid rewriting a whole table by mistake, use Where(Raw("TRUE")) for that
+func (b *UpdateBuilder) Build() (*Query, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if len(b.sets) == 0 {
+		return nil, errors.New("update sets no columns")
+	}
+
+	if len(b.where) == 0 {
+		return nil, errors.New("update without where")
+	}
+
+	sets, err := renderAssignments(b.sets)
+	if err != nil {
+		return nil, err
+	}
+
+	where, err := And(b.where...).render()
+	if err != nil {
+		return nil, err
+	}
+
+	return newQuery(fmt.Sprintf("UPDATE %s SET %s WHERE %s", b.table, sets, where))
+}
+
+// Builds a DELETE statement
+type DeleteBuilder struct {
+	errorHolder
+
+	table string
+	where []Condition
+}
+
+// Start a DELETE from a table
+func DeleteFrom(table string) *DeleteBuilder {
+	builder := &DeleteBuilder{}
+	builder.table, builder.err = identifierPath(table, false)
+	return builder
+}
+
+func (b *DeleteBuilder) Where(conditions ...Condition) *DeleteBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Deletes without Where are rejected like updates
+func (b *DeleteBuilder) Build() (*Query, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if len(b.where) == 0 {
+		return nil, errors.New("delete without where")
+	}
+
+	where, err := And(b.where...).render()
+	if err != nil {
+		return nil, err
+	}
+
+	return newQuery(fmt.Sprintf("DELETE FROM %s WHERE %s", b.table, where))
+}
+
+// Builds a MERGE statement
+//
+//	sqlbuilder.MergeInto("ETH.T AS TGT").Using("ETH.STAGING AS SRC").
+//		On(sqlbuilder.Eq("TGT.ID", sqlbuilder.Col("SRC.ID"))).
+//		WhenMatchedSet("TEST", sqlbuilder.Col("SRC.TEST")).
+//		WhenNotMatchedInsert([]string{"ID", "TEST"}, sqlbuilder.Col("SRC.ID"), sqlbuilder.Col("SRC.TEST"))
+type MergeBuilder struct {
+	errorHolder
+
+	table         string
+	using         string
+	on            Condition
+	matchedSets   []assignment
+	matchedDelete bool
+	insertColumns []string
+	insertValues  []interface{}
+}
+
+// Start a MERGE into a table, with an optional alias
+func MergeInto(table string) *MergeBuilder {
+	builder := &MergeBuilder{}
+	builder.table, builder.err = tableReference(table)
+	return builder
+}
+
+// Source table, with an optional alias
+func (b *MergeBuilder) Using(table string) *MergeBuilder {
+	reference, err := tableReference(table)
+	b.fail(err)
+	b.using = reference
+	return b
+}
+
+func (b *MergeBuilder) On(condition Condition) *MergeBuilder {
+	b.on = condition
+	return b
+}
+
+// Set a column of matched rows
+func (b *MergeBuilder) WhenMatchedSet(column string, value interface{}) *MergeBuilder {
+	name, err := identifierPath(column, false)
+	b.fail(err)
+	b.matchedSets = append(b.matchedSets, assignment{column: name, value: value})
+	return b
+}
+
+// Delete matched rows instead of updating them
+func (b *MergeBuilder) WhenMatchedDelete() *MergeBuilder {
+	b.matchedDelete = true
+	return b
+}
+
+// Insert rows without a match
+func (b *MergeBuilder) WhenNotMatchedInsert(columns []string, values ...interface{}) *MergeBuilder {
+	for _, column := range columns {
+		name, err := identifierPath(column, false)
+		b.fail(err)
+		b.insertColumns = append(b.insertColumns, name)
+	}
+	b.insertValues = values
+	return b
+}
+
+func (b *MergeBuilder) Build() (*Query, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if b.using == "" || b.on == nil {
+		return nil, errors.New("merge needs Using and On")
+	}
+
+	if len(b.matchedSets) > 0 && b.matchedDelete {
+		return nil, errors.New("matched rows can't be both updated and deleted")
+	}
+
+	if len(b.matchedSets) == 0 && !b.matchedDelete && len(b.insertColumns) == 0 {
+		return nil, errors.New("merge has no actions")
+	}
+
+	on, err := b.on.render()
+	if err != nil {
+		return nil, err
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "MERGE INTO %s USING %s ON %s", b.table, b.using, on)
+
+	if len(b.matchedSets) > 0 {
+		sets, err := renderAssignments(b.matchedSets)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(" WHEN MATCHED THEN UPDATE SET " + sets)
+	}
+
+	if b.matchedDelete {
+		builder.WriteString(" WHEN MATCHED THEN DELETE")
+	}
+
+	if len(b.insertColumns) > 0 {
+		if len(b.insertColumns) != len(b.insertValues) {
+			return nil, fmt.Errorf("%d values for %d insert columns", len(b.insertValues), len(b.insertColumns))
+		}
+
+		values, err := renderValues(b.insertValues)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&builder, " WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", strings.Join(b.insertColumns, ", "), values)
+	}
+
+	return newQuery(builder.String())
+}
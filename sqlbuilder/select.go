@@ -0,0 +1,177 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+type join struct {
+	kind  string
+	table string
+	on    Condition
+}
+
+// Builds a SELECT statement
+type SelectBuilder struct {
+	errorHolder
+
+	columns []string
+	from    string
+	joins   []join
+	where   []Condition
+	groupBy []string
+	having  []Condition
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// Start a SELECT of columns or expressions such as COUNT(*) AS N. No columns selects *
+func Select(columns ...string) *SelectBuilder {
+	builder := &SelectBuilder{}
+	for _, column := range columns {
+		expression, err := selectExpression(column)
+		builder.fail(err)
+		builder.columns = append(builder.columns, expression)
+	}
+
+	return builder
+}
+
+// Table to select from, with an optional alias: ETH.T or ETH.T AS A
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	reference, err := tableReference(table)
+	b.fail(err)
+	b.from = reference
+	return b
+}
+
+func (b *SelectBuilder) Join(table string, on Condition) *SelectBuilder {
+	return b.addJoin("JOIN", table, on)
+}
+
+func (b *SelectBuilder) LeftJoin(table string, on Condition) *SelectBuilder {
+	return b.addJoin("LEFT JOIN", table, on)
+}
+
+func (b *SelectBuilder) addJoin(kind, table string, on Condition) *SelectBuilder {
+	reference, err := tableReference(table)
+	b.fail(err)
+	b.joins = append(b.joins, join{kind: kind, table: reference, on: on})
+	return b
+}
+
+// Conditions are combined with AND, also over several calls
+func (b *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	for _, column := range columns {
+		name, err := identifierPath(column, false)
+		b.fail(err)
+		b.groupBy = append(b.groupBy, name)
+	}
+	return b
+}
+
+func (b *SelectBuilder) Having(conditions ...Condition) *SelectBuilder {
+	b.having = append(b.having, conditions...)
+	return b
+}
+
+// Terms are a column optionally followed by ASC or DESC, e.g. OrderBy("ID DESC")
+func (b *SelectBuilder) OrderBy(terms ...string) *SelectBuilder {
+	for _, term := range terms {
+		order, err := orderTerm(term)
+		b.fail(err)
+		b.orderBy = append(b.orderBy, order)
+	}
+	return b
+}
+
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	if limit < 0 {
+		b.fail(fmt.Errorf("invalid limit %d", limit))
+	}
+	b.limit = limit
+	return b
+}
+
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	if offset < 0 {
+		b.fail(fmt.Errorf("invalid offset %d", offset))
+	}
+	b.offset = offset
+	return b
+}
+
+func (b *SelectBuilder) Build() (*Query, error) {
+	sqlText, err := b.render()
+	if err != nil {
+		return nil, err
+	}
+
+	return newQuery(sqlText)
+}
+
+func (b *SelectBuilder) render() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+
+	if b.from == "" {
+		return "", errNoTable
+	}
+
+	columns := "*"
+	if len(b.columns) > 0 {
+		columns = strings.Join(b.columns, ", ")
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "SELECT %s FROM %s", columns, b.from)
+
+	for _, join := range b.joins {
+		on, err := join.on.render()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&builder, " %s %s ON %s", join.kind, join.table, on)
+	}
+
+	if len(b.where) > 0 {
+		where, err := And(b.where...).render()
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(" WHERE " + where)
+	}
+
+	if len(b.groupBy) > 0 {
+		builder.WriteString(" GROUP BY " + strings.Join(b.groupBy, ", "))
+	}
+
+	if len(b.having) > 0 {
+		having, err := And(b.having...).render()
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(" HAVING " + having)
+	}
+
+	if len(b.orderBy) > 0 {
+		builder.WriteString(" ORDER BY " + strings.Join(b.orderBy, ", "))
+	}
+
+	if b.limit > 0 {
+		fmt.Fprintf(&builder, " LIMIT %d", b.limit)
+	}
+
+	if b.offset > 0 {
+		fmt.Fprintf(&builder, " OFFSET %d", b.offset)
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,325 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Layout of timestamp literals, always rendered in UTC
+const timestampLiteralLayout = "2006-01-02 15:04:05.999999"
+
+// JSON number grammar
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// Run a DQL query with placeholders bound to args, see BindParams
+func (c *Client) DQLArgs(ctx context.Context, sqlText string, resources []string, args ...interface{}) (data []byte, err error) {
+	sqlText, err = BindParams(sqlText, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.DQL(ctx, sqlText, resources)
+}
+
+// Run a DML statement with placeholders bound to args, see BindParams
+func (c *Client) DMLArgs(ctx context.Context, sqlText string, resources []string, args ...interface{}) error {
+	sqlText, err := BindParams(sqlText, args...)
+	if err != nil {
+		return err
+	}
+
+	return c.DML(ctx, sqlText, resources)
+}
+
+// Replace the placeholders of a statement with args rendered as SQL literals by FormatLiteral.
+// Placeholders are either positional, ? in order or $1, $2..., or named :name with args given
+// as sql.Named values or a single map[string]interface{}. Styles can't be mixed, and every arg must be used.
+// Placeholders inside strings, quoted identifiers and comments are left alone
+func BindParams(sqlText string, args ...interface{}) (string, error) {
+	tokens, err := sqlparser.Tokenize(sqlText)
+	if err != nil {
+		return "", err
+	}
+
+	named, err := namedArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	style := ""
+	position := 0
+	last := 0
+	used := make([]bool, len(args))
+	usedNames := map[string]bool{}
+
+	for _, token := range tokens {
+		if token.Type != sqlparser.Param {
+			continue
+		}
+
+		tokenStyle := token.Text[:1]
+		if style == "" {
+			style = tokenStyle
+		} else if style != tokenStyle {
+			return "", fmt.Errorf("%s: placeholder styles can't be mixed", token.Pos)
+		}
+
+		var value interface{}
+		switch tokenStyle {
+		case "?":
+			if named != nil {
+				return "", fmt.Errorf("%s: positional placeholder with named args", token.Pos)
+			}
+			if position >= len(args) {
+				return "", fmt.Errorf("%s: not enough args, %d given", token.Pos, len(args))
+			}
+			value = args[position]
+			used[position] = true
+			position++
+
+		case "$":
+			if named != nil {
+				return "", fmt.Errorf("%s: positional placeholder with named args", token.Pos)
+			}
+			idx, err := strconv.Atoi(token.Value)
+			if err != nil || idx < 1 || idx > len(args) {
+				return "", fmt.Errorf("%s: %s is out of range, %d args given", token.Pos, token.Text, len(args))
+			}
+			value = args[idx-1]
+			used[idx-1] = true
+
+		case ":":
+			if named == nil {
+				return "", fmt.Errorf("%s: named placeholder %s without named args", token.Pos, token.Text)
+			}
+			var ok bool
+			value, ok = named[token.Value]
+			if !ok {
+				return "", fmt.Errorf("%s: no arg named %s", token.Pos, token.Value)
+			}
+			usedNames[token.Value] = true
+		}
+
+		literal, err := FormatLiteral(value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s: %w", token.Pos, token.Text, err)
+		}
+
+		before := sqlText[last:token.Pos.Offset]
+		builder.WriteString(before)
+		if strings.HasPrefix(literal, "-") && (strings.HasSuffix(before, "-") || (before == "" && strings.HasSuffix(builder.String(), "-"))) {
+			// x-? with a negative arg must not become a -- comment
+			builder.WriteString(" ")
+		}
+		builder.WriteString(literal)
+		last = token.Pos.Offset + len(token.Text)
+	}
+	builder.WriteString(sqlText[last:])
+
+	if named != nil {
+		for name := range named {
+			if !usedNames[name] {
+				return "", fmt.Errorf("arg %s is not used", name)
+			}
+		}
+	} else {
+		for idx := range args {
+			if !used[idx] {
+				return "", fmt.Errorf("arg %d is not used", idx+1)
+			}
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// Named args by name, nil when args are positional
+func namedArgs(args []interface{}) (map[string]interface{}, error) {
+	if len(args) == 1 {
+		if values, ok := args[0].(map[string]interface{}); ok {
+			return values, nil
+		}
+	}
+
+	var named map[string]interface{}
+	for idx, arg := range args {
+		namedArg, ok := arg.(sql.NamedArg)
+		if !ok {
+			if named != nil {
+				return nil, fmt.Errorf("arg %d: named and positional args can't be mixed", idx+1)
+			}
+			continue
+		}
+
+		if named == nil {
+			if idx > 0 {
+				return nil, fmt.Errorf("arg %d: named and positional args can't be mixed", idx+1)
+			}
+			named = map[string]interface{}{}
+		}
+		named[namedArg.Name] = namedArg.Value
+	}
+
+	return named, nil
+}
+
+// Render a Go value as an SxT SQL literal.
+// Supported are nil (NULL), strings, booleans, integers, finite floats, *big.Int, *big.Rat with a finite decimal
+// expansion, json.Number, time.Time (TIMESTAMP in UTC), []byte (hex X'..') and driver.Valuer types such as sql.Null*.
+// Pointers are followed, nil pointers are NULL. Other types are rejected rather than guessed
+func FormatLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteString(v)
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	case *big.Int:
+		if v == nil {
+			return "NULL", nil
+		}
+		return v.String(), nil
+	case *big.Rat:
+		if v == nil {
+			return "NULL", nil
+		}
+		return formatDecimal(v)
+	case json.Number:
+		if !numberPattern.MatchString(string(v)) {
+			return "", fmt.Errorf("invalid number %q", string(v))
+		}
+		return string(v), nil
+	case time.Time:
+		return "TIMESTAMP '" + v.UTC().Format(timestampLiteralLayout) + "'", nil
+	case []byte:
+		if v == nil {
+			return "NULL", nil
+		}
+		return "X'" + strings.ToUpper(hex.EncodeToString(v)) + "'", nil
+	case driver.Valuer:
+		if isNilPointer(value) {
+			return "NULL", nil
+		}
+		inner, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		if _, again := inner.(driver.Valuer); again {
+			return "", fmt.Errorf("%T returned another driver.Valuer", value)
+		}
+		return FormatLiteral(inner)
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() == reflect.Ptr {
+		if reflected.IsNil() {
+			return "NULL", nil
+		}
+		return FormatLiteral(reflected.Elem().Interface())
+	}
+
+	return "", fmt.Errorf("unsupported argument type %T", value)
+}
+
+// Single quoted string with quotes doubled. NUL bytes and invalid UTF-8 can't be stored and are rejected
+func quoteString(text string) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", errors.New("string is not valid UTF-8")
+	}
+
+	if strings.ContainsRune(text, 0) {
+		return "", errors.New("string contains a NUL byte")
+	}
+
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'", nil
+}
+
+func formatFloat(value float64, bitSize int) (string, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", fmt.Errorf("%v has no SQL literal", value)
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, bitSize), nil
+}
+
+// Exact decimal text of a rational, which needs a denominator of only 2s and 5s
+func formatDecimal(value *big.Rat) (string, error) {
+	if value.IsInt() {
+		return value.Num().String(), nil
+	}
+
+	denominator := new(big.Int).Set(value.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	remainder := new(big.Int)
+
+	digits := 0
+	for _, factor := range []*big.Int{two, five} {
+		count := 0
+		for {
+			quotient, mod := new(big.Int).QuoRem(denominator, factor, remainder)
+			if mod.Sign() != 0 {
+				break
+			}
+			denominator = quotient
+			count++
+		}
+		if count > digits {
+			digits = count
+		}
+	}
+
+	if denominator.Cmp(big.NewInt(1)) != 0 {
+		return "", fmt.Errorf("%s has no exact decimal representation", value.String())
+	}
+
+	return value.FloatString(digits), nil
+}
+
+func isNilPointer(value interface{}) bool {
+	reflected := reflect.ValueOf(value)
+	return reflected.Kind() == reflect.Ptr && reflected.IsNil()
+}
@@ -0,0 +1,129 @@
+package sqlcore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+func TestFormatLiteral(t *testing.T) {
+	name := "o'brien"
+	var nilName *string
+
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{"it's", "'it''s'"},
+		{`back\slash`, `'back\slash'`},
+		{true, "TRUE"},
+		{int8(-5), "-5"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{1.5, "1.5"},
+		{new(big.Int).Lsh(big.NewInt(1), 100), "1267650600228229401496703205376"},
+		{big.NewRat(1, 8), "0.125"},
+		{big.NewRat(-3, 20), "-0.15"},
+		{json.Number("12.50"), "12.50"},
+		{time.Date(2023, 3, 1, 12, 30, 0, 500000000, time.FixedZone("x", 3600)), "TIMESTAMP '2023-03-01 11:30:00.5'"},
+		{[]byte{0xde, 0xad}, "X'DEAD'"},
+		{&name, "'o''brien'"},
+		{nilName, "NULL"},
+		{sql.NullInt64{Int64: 3, Valid: true}, "3"},
+		{sql.NullString{}, "NULL"},
+	}
+
+	for _, c := range cases {
+		literal, err := FormatLiteral(c.value)
+		if err != nil {
+			t.Errorf("%#v: %v", c.value, err)
+			continue
+		}
+		if literal != c.expected {
+			t.Errorf("%#v: expected %s, got %s", c.value, c.expected, literal)
+		}
+	}
+
+	for _, value := range []interface{}{
+		math.NaN(), math.Inf(1), big.NewRat(1, 3), json.Number("1; DROP"), "nul\x00", "\xff", struct{}{}, []string{"a"},
+	} {
+		if literal, err := FormatLiteral(value); err == nil {
+			t.Errorf("%#v: expected an error, got %s", value, literal)
+		}
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	cases := []struct {
+		sqlText  string
+		args     []interface{}
+		expected string
+	}{
+		{"SELECT * FROM T WHERE A = ? AND B = ?", []interface{}{1, "x"}, "SELECT * FROM T WHERE A = 1 AND B = 'x'"},
+		{"SELECT * FROM T WHERE A = $2 OR B = $1 OR C = $2", []interface{}{"a", 2}, "SELECT * FROM T WHERE A = 2 OR B = 'a' OR C = 2"},
+		{"SELECT * FROM T WHERE A = :a AND B = :b", []interface{}{sql.Named("a", 1), sql.Named("b", nil)}, "SELECT * FROM T WHERE A = 1 AND B = NULL"},
+		{"SELECT ':x', \"?\" FROM T WHERE A = :x -- ?", []interface{}{map[string]interface{}{"x": "'"}}, "SELECT ':x', \"?\" FROM T WHERE A = '''' -- ?"},
+		{"SELECT A-? FROM T", []interface{}{-1}, "SELECT A- -1 FROM T"},
+		{"SELECT A::INT FROM T", nil, "SELECT A::INT FROM T"},
+	}
+
+	for _, c := range cases {
+		bound, err := BindParams(c.sqlText, c.args...)
+		if err != nil {
+			t.Errorf("%s: %v", c.sqlText, err)
+			continue
+		}
+		if bound != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.sqlText, c.expected, bound)
+		}
+	}
+
+	errorCases := []struct {
+		sqlText string
+		args    []interface{}
+	}{
+		{"SELECT ? FROM T", nil},
+		{"SELECT ? FROM T", []interface{}{1, 2}},
+		{"SELECT $3 FROM T", []interface{}{1, 2, 3, 4}},
+		{"SELECT ?, $1 FROM T", []interface{}{1}},
+		{"SELECT :a FROM T", []interface{}{1}},
+		{"SELECT :a FROM T", []interface{}{sql.Named("b", 1)}},
+		{"SELECT ? FROM T", []interface{}{sql.Named("a", 1), 2}},
+		{"SELECT ? FROM T", []interface{}{struct{}{}}},
+	}
+
+	for _, c := range errorCases {
+		if bound, err := BindParams(c.sqlText, c.args...); err == nil {
+			t.Errorf("%s %v: expected an error, got %s", c.sqlText, c.args, bound)
+		}
+	}
+}
+
+// A bound string must come back from the lexer as one string literal holding the same text
+func FuzzFormatLiteral(f *testing.F) {
+	for _, seed := range []string{"", "'", "''", "a'b", "--", "/* x */", `\'`, "é'ü", "?", ":a"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		bound, err := BindParams("SELECT * FROM T WHERE A = ?", text)
+		if err != nil {
+			return
+		}
+
+		tokens, err := sqlparser.Tokenize(bound)
+		if err != nil {
+			t.Fatalf("%q bound to unparsable %s: %v", text, bound, err)
+		}
+
+		// SELECT * FROM T WHERE A = <literal> EOF
+		if len(tokens) != 9 || tokens[7].Type != sqlparser.String || tokens[7].Value != text {
+			t.Fatalf("%q escaped into %s", text, bound)
+		}
+	})
+}
@@ -0,0 +1,306 @@
+package sqlcore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/storage"
+)
+
+// A biscuit held by the registry along with the capabilities it grants.
+// A zero ExpiresAt never expires
+type RegisteredBiscuit struct {
+	Token        string                           `json:"token"`
+	Capabilities []authorization.SxTBiscuitStruct `json:"capabilities"`
+	ExpiresAt    time.Time                        `json:"expiresAt,omitempty"`
+}
+
+func (b RegisteredBiscuit) expired(now time.Time) bool {
+	return !b.ExpiresAt.IsZero() && !now.Before(b.ExpiresAt)
+}
+
+// Persists the registry content. Implementations are provided for files and AWS secrets manager
+type BiscuitStore interface {
+	LoadBiscuits() ([]RegisteredBiscuit, error)
+	SaveBiscuits(biscuits []RegisteredBiscuit) error
+}
+
+// BiscuitRegistry indexes biscuits by resource and operation and picks the ones a statement needs
+type BiscuitRegistry struct {
+	mu       sync.Mutex
+	store    BiscuitStore
+	biscuits map[string]RegisteredBiscuit
+	index    map[capabilityKey]map[string]bool
+	now      func() time.Time
+}
+
+type capabilityKey struct {
+	operation string
+	resource  string
+}
+
+func newCapabilityKey(operation, resource string) capabilityKey {
+	return capabilityKey{operation: strings.ToLower(operation), resource: strings.ToLower(resource)}
+}
+
+// Create a biscuit registry. store is optional; when set, biscuits are loaded from it and every change is saved back
+func NewBiscuitRegistry(store BiscuitStore) (*BiscuitRegistry, error) {
+	registry := &BiscuitRegistry{
+		store:    store,
+		biscuits: map[string]RegisteredBiscuit{},
+		index:    map[capabilityKey]map[string]bool{},
+		now:      time.Now,
+	}
+
+	if store == nil {
+		return registry, nil
+	}
+
+	biscuits, err := store.LoadBiscuits()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, biscuit := range biscuits {
+		registry.add(biscuit)
+	}
+
+	return registry, nil
+}
+
+// Register a biscuit with the capabilities it was minted with
+func (r *BiscuitRegistry) Register(token string, capabilities []authorization.SxTBiscuitStruct, expiresAt time.Time) error {
+	if token == "" {
+		return errors.New("empty biscuit")
+	}
+
+	if len(capabilities) == 0 {
+		return errors.New("biscuit needs at least one capability")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.add(RegisteredBiscuit{Token: token, Capabilities: capabilities, ExpiresAt: expiresAt})
+
+	return r.save()
+}
+
+// Remove a biscuit from the registry
+func (r *BiscuitRegistry) Remove(token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.biscuits[token]; !ok {
+		return nil
+	}
+
+	r.remove(token)
+
+	return r.save()
+}
+
+// List the registered biscuits which are not expired
+func (r *BiscuitRegistry) List() []RegisteredBiscuit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictExpired()
+
+	biscuits := make([]RegisteredBiscuit, 0, len(r.biscuits))
+	for _, biscuit := range r.biscuits {
+		biscuits = append(biscuits, biscuit)
+	}
+
+	sort.Slice(biscuits, func(i, j int) bool { return biscuits[i].Token < biscuits[j].Token })
+
+	return biscuits
+}
+
+// Select the smallest set of biscuits granting operation on every resource.
+// Expired biscuits are evicted first. Resources are matched case insensitively and "*" operations match any operation
+func (r *BiscuitRegistry) Select(operation string, resources []string) (biscuitArray []string, err error) {
+	capabilities := make([]authorization.SxTBiscuitStruct, 0, len(resources))
+	for _, resource := range resources {
+		capabilities = append(capabilities, authorization.SxTBiscuitStruct{Operation: operation, Resource: resource})
+	}
+
+	return r.SelectCapabilities(capabilities)
+}
+
+// Select the smallest set of biscuits granting every capability
+func (r *BiscuitRegistry) SelectCapabilities(capabilities []authorization.SxTBiscuitStruct) (biscuitArray []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.evictExpired() {
+		if err := r.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	// token -> required capabilities it grants
+	uncovered := map[capabilityKey]bool{}
+	candidates := map[string]map[capabilityKey]bool{}
+	for _, capability := range capabilities {
+		required := newCapabilityKey(capability.Operation, capability.Resource)
+		uncovered[required] = true
+
+		found := false
+		for _, key := range []capabilityKey{required, newCapabilityKey("*", capability.Resource)} {
+			for token := range r.index[key] {
+				if candidates[token] == nil {
+					candidates[token] = map[capabilityKey]bool{}
+				}
+				candidates[token][required] = true
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("no registered biscuit grants %s on %s", required.operation, required.resource)
+		}
+	}
+
+	// Greedy set cover: take the biscuit covering most of what is left, then repeat
+	biscuitArray = []string{}
+	for len(uncovered) > 0 {
+		best, bestCount := "", 0
+		for token, covers := range candidates {
+			count := 0
+			for required := range covers {
+				if uncovered[required] {
+					count++
+				}
+			}
+
+			if count > bestCount || (count == bestCount && count > 0 && token < best) {
+				best, bestCount = token, count
+			}
+		}
+
+		for required := range candidates[best] {
+			delete(uncovered, required)
+		}
+		delete(candidates, best)
+		biscuitArray = append(biscuitArray, best)
+	}
+
+	return biscuitArray, nil
+}
+
+func (r *BiscuitRegistry) add(biscuit RegisteredBiscuit) {
+	if _, ok := r.biscuits[biscuit.Token]; ok {
+		r.remove(biscuit.Token)
+	}
+
+	r.biscuits[biscuit.Token] = biscuit
+	for _, capability := range biscuit.Capabilities {
+		key := newCapabilityKey(capability.Operation, capability.Resource)
+		if r.index[key] == nil {
+			r.index[key] = map[string]bool{}
+		}
+		r.index[key][biscuit.Token] = true
+	}
+}
+
+func (r *BiscuitRegistry) remove(token string) {
+	for _, capability := range r.biscuits[token].Capabilities {
+		key := newCapabilityKey(capability.Operation, capability.Resource)
+		delete(r.index[key], token)
+		if len(r.index[key]) == 0 {
+			delete(r.index, key)
+		}
+	}
+
+	delete(r.biscuits, token)
+}
+
+func (r *BiscuitRegistry) evictExpired() (evicted bool) {
+	now := r.now()
+	for token, biscuit := range r.biscuits {
+		if biscuit.expired(now) {
+			r.remove(token)
+			evicted = true
+		}
+	}
+
+	return evicted
+}
+
+func (r *BiscuitRegistry) save() error {
+	if r.store == nil {
+		return nil
+	}
+
+	biscuits := make([]RegisteredBiscuit, 0, len(r.biscuits))
+	for _, biscuit := range r.biscuits {
+		biscuits = append(biscuits, biscuit)
+	}
+
+	sort.Slice(biscuits, func(i, j int) bool { return biscuits[i].Token < biscuits[j].Token })
+
+	return r.store.SaveBiscuits(biscuits)
+}
+
+// Store registry biscuits in a local file next to the session file of `userId`
+type FileBiscuitStore struct {
+	UserId string
+}
+
+func (s FileBiscuitStore) LoadBiscuits() (biscuits []RegisteredBiscuit, err error) {
+	content, status := storage.FileReadBiscuits(s.UserId)
+	if !status {
+		return nil, nil
+	}
+
+	err = json.Unmarshal(content, &biscuits)
+	return biscuits, err
+}
+
+func (s FileBiscuitStore) SaveBiscuits(biscuits []RegisteredBiscuit) error {
+	content, err := json.Marshal(biscuits)
+	if err != nil {
+		return err
+	}
+
+	if !storage.FileWriteBiscuits(s.UserId, content) {
+		return errors.New("unable to write biscuits file")
+	}
+
+	return nil
+}
+
+// Store registry biscuits in AWS secrets manager next to the session of `userId`
+type AwsBiscuitStore struct {
+	UserId string
+}
+
+func (s AwsBiscuitStore) LoadBiscuits() (biscuits []RegisteredBiscuit, err error) {
+	content, status := storage.AwsReadBiscuits(s.UserId)
+	if !status {
+		return nil, nil
+	}
+
+	err = json.Unmarshal(content, &biscuits)
+	return biscuits, err
+}
+
+func (s AwsBiscuitStore) SaveBiscuits(biscuits []RegisteredBiscuit) error {
+	content, err := json.Marshal(biscuits)
+	if err != nil {
+		return err
+	}
+
+	if !storage.AwsWriteBiscuits(s.UserId, content) {
+		return errors.New("unable to write biscuits secret")
+	}
+
+	return nil
+}
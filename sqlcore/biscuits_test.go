@@ -0,0 +1,73 @@
+package sqlcore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+)
+
+type memoryBiscuitStore struct {
+	biscuits []RegisteredBiscuit
+}
+
+func (s *memoryBiscuitStore) LoadBiscuits() ([]RegisteredBiscuit, error) {
+	return s.biscuits, nil
+}
+
+func (s *memoryBiscuitStore) SaveBiscuits(biscuits []RegisteredBiscuit) error {
+	s.biscuits = biscuits
+	return nil
+}
+
+func TestBiscuitRegistrySelect(t *testing.T) {
+	registry, _ := NewBiscuitRegistry(nil)
+
+	registry.Register("a", []authorization.SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.t1"}}, time.Time{})
+	registry.Register("b", []authorization.SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.t2"}}, time.Time{})
+	registry.Register("c", []authorization.SxTBiscuitStruct{
+		{Operation: "dql_select", Resource: "eth.t1"},
+		{Operation: "*", Resource: "eth.t2"},
+	}, time.Time{})
+
+	biscuits, err := registry.Select("dql_select", []string{"ETH.T1", "ETH.T2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(biscuits, []string{"c"}) {
+		t.Errorf("expected the single covering biscuit, got %v", biscuits)
+	}
+
+	if _, err := registry.Select("dml_insert", []string{"ETH.T1"}); err == nil {
+		t.Error("expected an error for an uncovered operation")
+	}
+}
+
+func TestBiscuitRegistryEvictsExpired(t *testing.T) {
+	store := &memoryBiscuitStore{}
+	registry, _ := NewBiscuitRegistry(store)
+
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+
+	capabilities := []authorization.SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.t1"}}
+	registry.Register("old", capabilities, now.Add(time.Minute))
+	registry.Register("new", capabilities, now.Add(time.Hour))
+
+	now = now.Add(2 * time.Minute)
+
+	biscuits, err := registry.Select("dql_select", []string{"ETH.T1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(biscuits, []string{"new"}) {
+		t.Errorf("expected the expired biscuit to be skipped, got %v", biscuits)
+	}
+
+	if len(store.biscuits) != 1 {
+		t.Errorf("expected the eviction to be persisted, store has %d biscuits", len(store.biscuits))
+	}
+}
@@ -0,0 +1,422 @@
+package sqlcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Defaults used when BulkOptions fields are not set
+const (
+	DefaultBulkMaxRows     = 500
+	DefaultBulkMaxBytes    = 512 * 1024
+	DefaultBulkConcurrency = 4
+)
+
+// Rows read one at a time by BulkInsert, e.g. from a file or another query
+type RowSource interface {
+	// Columns of every row, in the order of the values
+	Columns() []string
+
+	// NextRow returns io.EOF after the last row
+	NextRow() (values []interface{}, err error)
+}
+
+// Settings for Client.BulkInsert
+type BulkOptions struct {
+	// MaxRows is the most rows per INSERT statement, DefaultBulkMaxRows when zero
+	MaxRows int
+
+	// MaxBytes is the largest INSERT statement, DefaultBulkMaxBytes when zero. A single larger row is sent alone
+	MaxBytes int
+
+	// Concurrency is the number of statements sent at once, DefaultBulkConcurrency when zero
+	Concurrency int
+
+	// Columns inserted from maps, in order. Defaults to the sorted keys of the first map
+	Columns []string
+}
+
+// Outcome of one INSERT statement. Rows [Offset, Offset+Rows) of the input were sent in it
+type ChunkResult struct {
+	Offset int
+	Rows   int
+	Err    error
+}
+
+// Chunk outcomes in input order
+type ChunkResults []ChunkResult
+
+// Chunks that failed, to be retried
+func (cr ChunkResults) Failed() (failed []ChunkResult) {
+	for _, chunk := range cr {
+		if chunk.Err != nil {
+			failed = append(failed, chunk)
+		}
+	}
+
+	return failed
+}
+
+// First chunk error, nil when every chunk succeeded
+func (cr ChunkResults) Err() error {
+	for _, chunk := range cr {
+		if chunk.Err != nil {
+			return fmt.Errorf("rows %d to %d: %w", chunk.Offset, chunk.Offset+chunk.Rows-1, chunk.Err)
+		}
+	}
+
+	return nil
+}
+
+// Outcome of a bulk insert
+type BulkResult struct {
+	Chunks   ChunkResults
+	Inserted int
+}
+
+// Chunks that failed, to be retried
+func (r *BulkResult) Failed() []ChunkResult {
+	return r.Chunks.Failed()
+}
+
+// First chunk error, nil when every chunk was inserted
+func (r *BulkResult) Err() error {
+	return r.Chunks.Err()
+}
+
+type insertChunk struct {
+	offset  int
+	rows    int
+	sqlText string
+}
+
+// Insert rows into a table with multi-row INSERT statements.
+// rows is a slice of structs (or struct pointers) mapped to columns like QueryInto, a slice of
+// map[string]interface{} or a RowSource. Values are rendered by FormatLiteral.
+// The returned error covers bad input only: chunk failures are reported in the result, with their row offsets
+func (c *Client) BulkInsert(ctx context.Context, table string, rows interface{}, options BulkOptions) (*BulkResult, error) {
+	if !isTableName(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	source, err := rowSourceOf(rows, options.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Encryption != nil {
+		source = c.Encryption.source(ctx, table, source)
+	}
+
+	if options.MaxRows <= 0 {
+		options.MaxRows = DefaultBulkMaxRows
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = DefaultBulkMaxBytes
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultBulkConcurrency
+	}
+
+	columns := source.Columns()
+
+	quoted := make([]string, len(columns))
+	for idx, column := range columns {
+		quoted[idx] = quoteIdentifier(column)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table, strings.Join(quoted, ", "))
+	resources := []string{table}
+
+	result := &BulkResult{}
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	slots := make(chan struct{}, options.Concurrency)
+
+	send := func(chunk insertChunk) {
+		slots <- struct{}{}
+		wait.Add(1)
+
+		go func() {
+			defer wait.Done()
+			defer func() { <-slots }()
+
+			err := ctx.Err()
+			if err == nil {
+				err = c.DML(ctx, chunk.sqlText, resources)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			result.Chunks = append(result.Chunks, ChunkResult{Offset: chunk.offset, Rows: chunk.rows, Err: err})
+			if err == nil {
+				result.Inserted += chunk.rows
+			}
+		}()
+	}
+
+	var builder strings.Builder
+	chunk := insertChunk{}
+	offset := 0
+
+	flush := func() {
+		if chunk.rows > 0 {
+			chunk.sqlText = prefix + builder.String()
+			send(chunk)
+		}
+		builder.Reset()
+		chunk = insertChunk{offset: offset}
+	}
+
+	var sourceErr error
+	for ctx.Err() == nil {
+		values, err := source.NextRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			sourceErr = fmt.Errorf("row %d: %w", offset, err)
+			break
+		}
+
+		if len(columns) == 0 {
+			sourceErr = errors.New("rows have no columns")
+			break
+		}
+
+		tuple, err := formatTuple(values, len(columns))
+		if err != nil {
+			sourceErr = fmt.Errorf("row %d: %w", offset, err)
+			break
+		}
+
+		if chunk.rows > 0 && (chunk.rows >= options.MaxRows || len(prefix)+builder.Len()+2+len(tuple) > options.MaxBytes) {
+			flush()
+		}
+
+		if chunk.rows > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(tuple)
+		chunk.rows++
+		offset++
+	}
+
+	// Rows read before a bad row are still sent, so the result shows what was inserted
+	flush()
+	wait.Wait()
+
+	sort.Slice(result.Chunks, func(i, j int) bool {
+		return result.Chunks[i].Offset < result.Chunks[j].Offset
+	})
+
+	if sourceErr != nil {
+		return result, sourceErr
+	}
+
+	return result, ctx.Err()
+}
+
+func formatTuple(values []interface{}, columns int) (string, error) {
+	if len(values) != columns {
+		return "", fmt.Errorf("%d values for %d columns", len(values), columns)
+	}
+
+	literals := make([]string, len(values))
+	for idx, value := range values {
+		literal, err := FormatLiteral(value)
+		if err != nil {
+			return "", err
+		}
+		literals[idx] = literal
+	}
+
+	return "(" + strings.Join(literals, ", ") + ")", nil
+}
+
+// Names such as SCHEMA.TABLE or "Schema"."Table"
+func isTableName(table string) bool {
+	tokens, err := sqlparser.Tokenize(table)
+	if err != nil || len(tokens) == 0 {
+		return false
+	}
+
+	// The last token is EOF
+	tokens = tokens[:len(tokens)-1]
+	if len(tokens)%2 == 0 {
+		return false
+	}
+
+	for idx, token := range tokens {
+		if idx%2 == 1 {
+			if token.Type != sqlparser.Punct || token.Text != "." {
+				return false
+			}
+		} else if token.Type != sqlparser.Ident && token.Type != sqlparser.QuotedIdent {
+			return false
+		}
+	}
+
+	return true
+}
+
+func rowSourceOf(rows interface{}, columns []string) (RowSource, error) {
+	if source, ok := rows.(RowSource); ok {
+		return source, nil
+	}
+
+	if maps, ok := rows.([]map[string]interface{}); ok {
+		return newMapSource(maps, columns), nil
+	}
+
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot insert %T, a slice or RowSource is expected", rows)
+	}
+
+	elemType := value.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot insert %T, a slice of structs or maps is expected", rows)
+	}
+
+	return newStructSource(value, structType), nil
+}
+
+type mapSource struct {
+	rows    []map[string]interface{}
+	columns []string
+	next    int
+}
+
+func newMapSource(rows []map[string]interface{}, columns []string) *mapSource {
+	if columns == nil && len(rows) > 0 {
+		for column := range rows[0] {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+	}
+
+	return &mapSource{rows: rows, columns: columns}
+}
+
+func (s *mapSource) Columns() []string {
+	return s.columns
+}
+
+// Missing keys are NULL, unknown keys are an error
+func (s *mapSource) NextRow() ([]interface{}, error) {
+	if s.next >= len(s.rows) {
+		return nil, io.EOF
+	}
+
+	row := s.rows[s.next]
+	s.next++
+
+	values := make([]interface{}, len(s.columns))
+	found := 0
+	for idx, column := range s.columns {
+		if value, ok := row[column]; ok {
+			values[idx] = value
+			found++
+		}
+	}
+
+	if found != len(row) {
+		return nil, fmt.Errorf("row has keys outside columns %v", s.columns)
+	}
+
+	return values, nil
+}
+
+type structSource struct {
+	rows    reflect.Value
+	columns []string
+	paths   [][]int
+	next    int
+}
+
+// Columns of a struct in field order, named like ScanInto maps them
+func newStructSource(rows reflect.Value, structType reflect.Type) *structSource {
+	fields := structFields(structType)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return indexLess(fields[names[i]].index, fields[names[j]].index)
+	})
+
+	source := &structSource{rows: rows, columns: names}
+	for _, name := range names {
+		source.paths = append(source.paths, fields[name].index)
+	}
+
+	return source
+}
+
+func (s *structSource) Columns() []string {
+	return s.columns
+}
+
+func (s *structSource) NextRow() ([]interface{}, error) {
+	if s.next >= s.rows.Len() {
+		return nil, io.EOF
+	}
+
+	row := s.rows.Index(s.next)
+	s.next++
+
+	if row.Kind() == reflect.Ptr {
+		if row.IsNil() {
+			return nil, errors.New("nil row")
+		}
+		row = row.Elem()
+	}
+
+	values := make([]interface{}, len(s.paths))
+	for idx, path := range s.paths {
+		field, ok := fieldValue(row, path)
+		if ok && field.CanInterface() {
+			values[idx] = field.Interface()
+		}
+	}
+
+	return values, nil
+}
+
+// Field by index path without allocating. Fields under nil embedded pointers are not there
+func fieldValue(value reflect.Value, index []int) (reflect.Value, bool) {
+	for depth, idx := range index {
+		if depth > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(idx)
+	}
+
+	return value, true
+}
+
+func indexLess(a, b []int) bool {
+	for idx := 0; idx < len(a) && idx < len(b); idx++ {
+		if a[idx] != b[idx] {
+			return a[idx] < b[idx]
+		}
+	}
+
+	return len(a) < len(b)
+}
@@ -0,0 +1,114 @@
+package sqlcore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Records the statements sent and fails the ones containing fail. respond gives the response body, [] when nil
+type recordingTransport struct {
+	mutex      sync.Mutex
+	statements []string
+	endpoints  []string
+	fail       string
+	respond    func(sqlText string) string
+}
+
+func (rt *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body struct {
+		SqlText string `json:"sqlText"`
+	}
+	json.NewDecoder(request.Body).Decode(&body)
+
+	rt.mutex.Lock()
+	rt.statements = append(rt.statements, body.SqlText)
+	rt.endpoints = append(rt.endpoints, request.URL.Path)
+	rt.mutex.Unlock()
+
+	status := 200
+	if rt.fail != "" && strings.Contains(body.SqlText, rt.fail) {
+		status = 400
+	}
+
+	response := "[]"
+	if rt.respond != nil {
+		response = rt.respond(body.SqlText)
+	}
+
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(response)), Header: http.Header{}}, nil
+}
+
+type bulkRow struct {
+	ID   int64
+	Name *string `sxt:"TEST"`
+	Skip string  `sxt:"-"`
+}
+
+func TestBulkInsertStructs(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	transport := &recordingTransport{fail: "(3, "}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	name := "it's"
+	rows := make([]bulkRow, 5)
+	for idx := range rows {
+		rows[idx] = bulkRow{ID: int64(idx), Name: &name}
+	}
+	rows[4].Name = nil
+
+	result, err := client.BulkInsert(context.Background(), "ETH.TESTTABLE103", rows, BulkOptions{MaxRows: 2, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Chunks) != 3 || result.Inserted != 3 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].Offset != 2 || failed[0].Rows != 2 {
+		t.Errorf("expected rows 2-3 to fail, got %+v", failed)
+	}
+
+	found := false
+	for _, statement := range transport.statements {
+		if statement == "INSERT INTO ETH.TESTTABLE103 (ID, TEST) VALUES (4, NULL)" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("last chunk not sent as expected: %v", transport.statements)
+	}
+}
+
+func TestBulkInsertMaps(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	transport := &recordingTransport{}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	rows := []map[string]interface{}{{"ID": 1, "TEST": "a"}, {"ID": 2}}
+	result, err := client.BulkInsert(context.Background(), "ETH.T", rows, BulkOptions{MaxBytes: 40, Concurrency: 1})
+	if err != nil || result.Err() != nil {
+		t.Fatal(err, result.Err())
+	}
+
+	// The byte budget splits the rows
+	if len(transport.statements) != 2 || transport.statements[0] != "INSERT INTO ETH.T (ID, TEST) VALUES (1, 'a')" {
+		t.Errorf("unexpected statements %v", transport.statements)
+	}
+
+	if _, err := client.BulkInsert(context.Background(), "ETH.T; DROP TABLE X", rows, BulkOptions{}); err == nil {
+		t.Error("expected an invalid table name error")
+	}
+
+	if _, err := client.BulkInsert(context.Background(), "ETH.T", []map[string]interface{}{{"ID": 1}, {"OTHER": 2}}, BulkOptions{}); err == nil {
+		t.Error("expected an unknown key error")
+	}
+}
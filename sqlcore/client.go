@@ -0,0 +1,361 @@
+package sqlcore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Lifetime of auto minted biscuits when Client.MintTTL is not set
+const DefaultMintTTL = 5 * time.Minute
+
+// Client runs sqlcore requests with per caller settings.
+// Biscuits are attached automatically from the registry, so callers don't pass biscuitArray by hand
+type Client struct {
+	OriginApp string
+
+	// AccessToken falls back to the `accessToken` environment variable when empty
+	AccessToken string
+
+	// HTTPClient falls back to http.DefaultClient when nil
+	HTTPClient *http.Client
+
+	// Biscuits is optional. Without a registry no biscuits are sent
+	Biscuits *BiscuitRegistry
+
+	// MintKey turns on auto minting: every statement is analyzed and sent with a short lived biscuit
+	// signed by this key, granting exactly the statement's operation on its tables. Use it for tables you own
+	MintKey ed25519.PrivateKey
+
+	// MintTTL is the lifetime of auto minted biscuits, DefaultMintTTL when zero
+	MintTTL time.Duration
+
+	// StrictScan makes QueryInto fail on unmapped columns, missing columns and NULLs in non nullable fields
+	StrictScan bool
+
+	// Ledger is optional. When set, auto minted biscuits are recorded in it
+	// and revoked biscuits are dropped from the registry instead of being sent
+	Ledger authorization.BiscuitLedger
+
+	// Encryption is optional. When set, encrypted columns are encrypted by BulkInsert and Upsert
+	// and decrypted in query results
+	Encryption *ColumnEncryption
+
+	// Preflight is optional. When set, it checks every statement before it is sent, e.g. sqlvalidate.Validator.Preflight
+	Preflight func(sqlText string) error
+
+	// DescribeTable is optional. It returns the columns and primary key of a SCHEMA.TABLE, no columns when it doesn't exist.
+	// Set it when the client targets another environment than the discovery package, e.g. the destination of CopyTable.
+	// Defaults to the discovery APIs
+	DescribeTable func(table string) (columns []discovery.Column, primaryKey []string, err error)
+}
+
+// Error returned when the gateway answers with a non 200 status
+type GatewayError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *GatewayError) Error() string {
+	return fmt.Sprintf("gateway returned %d: %s", e.StatusCode, e.Body)
+}
+
+// Create a new client for the given origin app
+func NewClient(originApp string) *Client {
+	return &Client{OriginApp: originApp}
+}
+
+// Run DDL queries: CREATE, ALTER and DROP
+// resources are the schemas or tables the statement touches, used to select biscuits.
+// When nil, they are found by analyzing the statement
+func (c *Client) DDL(ctx context.Context, sqlText string, resources []string) error {
+	biscuitArray, _, err := c.prepare(sqlText, resources)
+	if err != nil {
+		return err
+	}
+
+	postBody, _ := json.Marshal(map[string]interface{}{
+		"biscuits": biscuitArray,
+		"sqlText":  sqlText,
+	})
+
+	if _, err := c.execute(ctx, "ddl", postBody); err != nil {
+		return err
+	}
+
+	invalidateDiscovery(sqlText)
+	return nil
+}
+
+// Run DML queries: INSERT, UPDATE, MERGE and DELETE
+func (c *Client) DML(ctx context.Context, sqlText string, resources []string) error {
+	_, err := c.dml(ctx, sqlText, resources)
+	return err
+}
+
+// Run a DML statement and return the gateway response, which may hold affected row counts
+func (c *Client) dml(ctx context.Context, sqlText string, resources []string) (data []byte, err error) {
+	biscuitArray, resources, err := c.prepare(sqlText, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	postBody, _ := json.Marshal(map[string]interface{}{
+		"biscuits":  biscuitArray,
+		"resources": resources,
+		"sqlText":   sqlText,
+	})
+
+	return c.execute(ctx, "dml", postBody)
+}
+
+// Run DQL queries and return the raw response body
+func (c *Client) DQL(ctx context.Context, sqlText string, resources []string) (data []byte, err error) {
+	return c.DQLWithRowCount(ctx, sqlText, resources, 0)
+}
+
+// Run DQL queries returning at most rowCount rows. If rowCount is 0, then fetches all data without limit
+func (c *Client) DQLWithRowCount(ctx context.Context, sqlText string, resources []string, rowCount int) (data []byte, err error) {
+	biscuitArray, resources, err := c.prepare(sqlText, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(ctx, "dql", queryExecutionBody(sqlText, biscuitArray, resources, rowCount))
+}
+
+// Find the biscuits and resources to send with a statement
+func (c *Client) prepare(sqlText string, resources []string) (biscuitArray, statementResources []string, err error) {
+	if c.Preflight != nil {
+		if err := c.Preflight(sqlText); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	statement, analyzeErr := sqlparser.Analyze(sqlText)
+
+	if c.MintKey != nil {
+		if analyzeErr != nil {
+			return nil, nil, fmt.Errorf("unable to mint a biscuit, statement can't be analyzed: %w", analyzeErr)
+		}
+
+		if resources == nil {
+			resources = statement.Resources()
+		}
+
+		biscuitArray, err = c.mint(statement.Capabilities())
+		return biscuitArray, resources, err
+	}
+
+	if analyzeErr != nil && resources == nil && c.Biscuits != nil {
+		return nil, nil, fmt.Errorf("unable to find the statement resources: %w", analyzeErr)
+	}
+
+	if c.Biscuits == nil {
+		if resources == nil && analyzeErr == nil {
+			resources = statement.Resources()
+		}
+		return []string{}, resources, nil
+	}
+
+	// Resources need exactly the statement capabilities, e.g. dql_select on the sources of an INSERT ... SELECT.
+	// Listed resources the analysis doesn't find, or all of them when it fails, need the statement operation
+	var capabilities []authorization.SxTBiscuitStruct
+	if resources == nil {
+		capabilities = statement.Capabilities()
+		resources = statement.Resources()
+	} else {
+		operation := statementOperation(sqlText)
+		var analyzed []authorization.SxTBiscuitStruct
+		if analyzeErr == nil {
+			operation = statement.Operation
+			analyzed = statement.Capabilities()
+		}
+
+		for _, resource := range resources {
+			found := false
+			for _, capability := range analyzed {
+				if strings.EqualFold(capability.Resource, resource) {
+					capabilities = append(capabilities, capability)
+					found = true
+				}
+			}
+			if !found {
+				capabilities = append(capabilities, authorization.SxTBiscuitStruct{Operation: operation, Resource: resource})
+			}
+		}
+	}
+
+	biscuitArray, err = c.selectUnrevoked(capabilities)
+	return biscuitArray, resources, err
+}
+
+// Select registry biscuits, dropping revoked ones from the registry until the selection is clean
+func (c *Client) selectUnrevoked(capabilities []authorization.SxTBiscuitStruct) (biscuitArray []string, err error) {
+	for {
+		biscuitArray, err = c.Biscuits.SelectCapabilities(capabilities)
+		if err != nil || c.Ledger == nil {
+			return biscuitArray, err
+		}
+
+		clean := true
+		for _, biscuit := range biscuitArray {
+			revoked, err := authorization.IsBiscuitRevoked(c.Ledger, biscuit)
+			if err != nil {
+				return nil, err
+			}
+
+			if revoked {
+				clean = false
+				if err := c.Biscuits.Remove(biscuit); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if clean {
+			return biscuitArray, nil
+		}
+	}
+}
+
+// Biscuits for capabilities known up front: minted with MintKey, else selected from the registry
+func (c *Client) biscuitsFor(capabilities []authorization.SxTBiscuitStruct) (biscuitArray []string, err error) {
+	if c.MintKey != nil {
+		return c.mint(capabilities)
+	}
+
+	if c.Biscuits == nil {
+		return []string{}, nil
+	}
+
+	return c.selectUnrevoked(capabilities)
+}
+
+// Mint a single statement biscuit with the capabilities the statement needs
+func (c *Client) mint(capabilities []authorization.SxTBiscuitStruct) (biscuitArray []string, err error) {
+	if len(capabilities) == 0 {
+		return []string{}, nil
+	}
+
+	ttl := c.MintTTL
+	if ttl == 0 {
+		ttl = DefaultMintTTL
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	if c.Ledger != nil {
+		biscuit, err := authorization.CreateTrackedBiscuitToken(c.Ledger, "sqlcore auto mint: "+c.OriginApp, capabilities, &c.MintKey, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{biscuit}, nil
+	}
+
+	biscuit, status := authorization.CreateBiscuitTokenWithExpiry(capabilities, &c.MintKey, expiresAt)
+	if !status {
+		return nil, errors.New("unable to mint biscuit")
+	}
+
+	return []string{biscuit}, nil
+}
+
+func (c *Client) execute(ctx context.Context, requestType string, postBody []byte) (body []byte, err error) {
+	response, err := c.send(ctx, requestType, postBody)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	body, err = io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != 200 {
+		return nil, &GatewayError{StatusCode: response.StatusCode, Body: string(body)}
+	}
+
+	return body, nil
+}
+
+func (c *Client) send(ctx context.Context, requestType string, postBody []byte) (response *http.Response, err error) {
+	accessToken := c.AccessToken
+	if accessToken == "" {
+		accessToken = os.Getenv("accessToken")
+	}
+
+	request, err := createRequestWithContext(ctx, requestType, c.OriginApp, accessToken, postBody)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return httpClient.Do(request)
+}
+
+// Drop the cached discovery metadata a DDL statement changed, everything when it can't be analyzed
+func invalidateDiscovery(sqlText string) {
+	statement, err := sqlparser.Analyze(sqlText)
+	if err != nil || statement.Target == nil {
+		discovery.Invalidate("", "")
+		return
+	}
+
+	target := statement.Target
+	switch {
+	case statement.Object == "SCHEMA":
+		discovery.Invalidate(target.Name, "")
+	case statement.Object == "INDEX" && statement.Operation != "ddl_create":
+		// DROP INDEX names the index, not its table
+		discovery.Invalidate(target.Schema, "")
+	default:
+		discovery.Invalidate(target.Schema, target.Name)
+	}
+}
+
+// Map the leading keyword of a statement to its biscuit operation.
+// Used for statements the analyzer doesn't support when the caller lists resources
+func statementOperation(sqlText string) (operation string) {
+	fields := strings.Fields(sqlText)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "CREATE":
+		return "ddl_create"
+	case "ALTER":
+		return "ddl_alter"
+	case "DROP":
+		return "ddl_drop"
+	case "INSERT":
+		return "dml_insert"
+	case "UPDATE":
+		return "dml_update"
+	case "MERGE":
+		return "dml_merge"
+	case "DELETE":
+		return "dml_delete"
+	case "SELECT", "WITH":
+		return "dql_select"
+	}
+
+	return ""
+}
@@ -0,0 +1,76 @@
+package sqlcore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+)
+
+func TestDDLInvalidatesDiscovery(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		requests[request.URL.Query().Get("table")]++
+		w.Write([]byte(`[{"column": "ID", "dataType": "BIGINT"}]`))
+	}))
+	defer server.Close()
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	t.Setenv("BASEURL_DISCOVERY", server.URL)
+
+	discovery.UseCache(discovery.NewCache(time.Minute))
+	defer discovery.UseCache(nil)
+
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: &recordingTransport{fail: "BROKEN"}}
+
+	lookup := func() {
+		discovery.ListColumns("ETH", "BLOCKS")
+		discovery.ListColumns("ETH", "TX")
+	}
+
+	lookup()
+	if err := client.DDL(context.Background(), "ALTER TABLE ETH.BLOCKS ADD COLUMN HASH VARCHAR", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DDL(context.Background(), "DROP TABLE ETH.BROKEN", nil); err == nil {
+		t.Fatal("expected the DDL to fail")
+	}
+	lookup()
+
+	if requests["BLOCKS"] != 2 || requests["TX"] != 1 {
+		t.Errorf("expected only ETH.BLOCKS to be fetched again, got %v", requests)
+	}
+
+	if err := client.DDL(context.Background(), "DROP SCHEMA ETH", nil); err != nil {
+		t.Fatal(err)
+	}
+	lookup()
+
+	if requests["BLOCKS"] != 3 || requests["TX"] != 2 {
+		t.Errorf("expected the schema to be fetched again, got %v", requests)
+	}
+}
+
+func TestPrepareListedResources(t *testing.T) {
+	registry, _ := NewBiscuitRegistry(nil)
+	registry.Register("insert", []authorization.SxTBiscuitStruct{{Operation: "dml_insert", Resource: "eth.a"}}, time.Time{})
+	registry.Register("select", []authorization.SxTBiscuitStruct{{Operation: "dql_select", Resource: "eth.b"}}, time.Time{})
+
+	client := NewClient("test")
+	client.Biscuits = registry
+
+	// The listed source is only read, so its select biscuit is enough
+	biscuitArray, _, err := client.prepare("INSERT INTO ETH.A SELECT * FROM ETH.B", []string{"ETH.A", "ETH.B"})
+	if err != nil || !reflect.DeepEqual(biscuitArray, []string{"insert", "select"}) {
+		t.Errorf("expected the insert and select biscuits, got %v: %v", biscuitArray, err)
+	}
+
+	if _, _, err := client.prepare("INSERT INTO ETH.A SELECT * FROM ETH.B", []string{"ETH.A", "ETH.C"}); err == nil {
+		t.Error("expected a listed resource the statement doesn't read to need the statement operation")
+	}
+}
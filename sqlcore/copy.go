@@ -0,0 +1,288 @@
+package sqlcore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Settings for Client.CopyTable
+type CopyOptions struct {
+	// Destination writes the destination table, e.g. a client of another environment, whose DescribeTable then
+	// looks up the destination. The client CopyTable is called on reads the source. Defaults to the same client
+	Destination *Client
+
+	// Columns maps source columns to destination columns. Only mapped columns are copied, all of them when empty
+	Columns map[string]string
+
+	// Where filters the source rows, a condition such as BLOCK_NUMBER > 100
+	Where string
+
+	// KeyColumns order the source rows for keyset pagination. Defaults to the source primary key
+	KeyColumns []string
+
+	// PageSize is the number of rows read per DQL query, DefaultPageSize when zero
+	PageSize int
+
+	// Create creates the destination table with these options when it doesn't exist,
+	// with the source columns and primary key. Without it the destination must exist
+	Create *TableOptions
+
+	// Upsert writes pages with MERGE on the destination primary key instead of INSERT,
+	// so resuming after a partly written page doesn't duplicate rows
+	Upsert bool
+
+	// Bulk sets the INSERT statement sizes and concurrency
+	Bulk BulkOptions
+
+	// Cursor resumes a copy after the last page written, from CopyResult.Cursor
+	Cursor string
+}
+
+// Outcome of a copy
+type CopyResult struct {
+	// Copied counts the rows written by this call
+	Copied int
+
+	// Created is set when the destination table was created
+	Created bool
+
+	// Cursor resumes the copy after the last page fully written. Pass it as CopyOptions.Cursor after a failure
+	Cursor string
+}
+
+// Copy the rows of a table into another table, e.g. from a dev schema to prod. Source rows are read page by page
+// with keyset pagination and written with BulkInsert, or Upsert when set, on the destination client.
+// The destination table is created from the source metadata when CopyOptions.Create is set.
+// On failure the result holds the rows copied so far and the cursor to resume from
+func (c *Client) CopyTable(ctx context.Context, src, dst string, options CopyOptions) (*CopyResult, error) {
+	if !isTableName(src) {
+		return nil, fmt.Errorf("invalid table name %q", src)
+	}
+	if !isTableName(dst) {
+		return nil, fmt.Errorf("invalid table name %q", dst)
+	}
+
+	destination := options.Destination
+	if destination == nil {
+		destination = c
+	}
+
+	columns, primaryKey, err := c.liveTable(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s doesn't exist", src)
+	}
+
+	plan, err := newCopyPlan(columns, options.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	keyColumns := options.KeyColumns
+	if len(keyColumns) == 0 {
+		keyColumns = primaryKey
+	}
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("%s has no primary key, give KeyColumns to order its rows", src)
+	}
+
+	result := &CopyResult{Cursor: options.Cursor}
+	if options.Cursor == "" {
+		result.Created, err = destination.ensureCopyTable(ctx, dst, plan, primaryKey, options.Create)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	selected := append([]string{}, plan.source...)
+	for _, key := range keyColumns {
+		if !isKey(selected, key) {
+			selected = append(selected, key)
+		}
+	}
+
+	sqlText := fmt.Sprintf("SELECT %s FROM %s", quoteIdentifiers(selected), src)
+	if options.Where != "" {
+		sqlText += " WHERE " + options.Where
+	}
+
+	paginator, err := c.Paginate(sqlText, []string{src}, PageOptions{PageSize: options.PageSize, KeyColumns: keyColumns, Cursor: options.Cursor})
+	if err != nil {
+		return result, err
+	}
+
+	var destinationKeys []string
+	if options.Upsert {
+		for _, key := range primaryKey {
+			if mapped, ok := plan.mapped(key); ok {
+				destinationKeys = append(destinationKeys, mapped)
+			}
+		}
+	}
+
+	for paginator.Next(ctx) {
+		source := &pageSource{rows: paginator.Page().Rows, sourceColumns: plan.source, columns: plan.destination}
+
+		var written int
+		if options.Upsert {
+			upserted, err := destination.UpsertWithOptions(ctx, dst, source, UpsertOptions{KeyColumns: destinationKeys, MaxRows: options.Bulk.MaxRows})
+			if err == nil {
+				err = upserted.Err()
+			}
+			if err != nil {
+				return result, fmt.Errorf("copy to %s: %w", dst, err)
+			}
+			written = len(source.rows)
+		} else {
+			inserted, err := destination.BulkInsert(ctx, dst, source, options.Bulk)
+			if err == nil {
+				err = inserted.Err()
+			}
+			if err != nil {
+				if inserted != nil {
+					result.Copied += inserted.Inserted
+				}
+				return result, fmt.Errorf("copy to %s: %w", dst, err)
+			}
+			written = inserted.Inserted
+		}
+
+		result.Copied += written
+		result.Cursor = paginator.Cursor()
+	}
+
+	if err := paginator.Err(); err != nil {
+		return result, fmt.Errorf("copy from %s: %w", src, err)
+	}
+
+	result.Cursor = paginator.Cursor()
+	return result, nil
+}
+
+// Source columns copied and the destination columns they are written to, in source order
+type copyPlan struct {
+	source      []string
+	destination []string
+	types       map[string]liveColumn
+}
+
+func newCopyPlan(columns []liveColumn, mapping map[string]string) (*copyPlan, error) {
+	plan := &copyPlan{types: map[string]liveColumn{}}
+	found := map[string]bool{}
+
+	for _, column := range columns {
+		target := column.Column
+		if len(mapping) > 0 {
+			var ok bool
+			target, ok = lookupFold(mapping, column.Column)
+			if !ok {
+				continue
+			}
+			found[strings.ToUpper(column.Column)] = true
+		}
+
+		plan.source = append(plan.source, column.Column)
+		plan.destination = append(plan.destination, target)
+		plan.types[strings.ToUpper(column.Column)] = column
+	}
+
+	for source := range mapping {
+		if !found[strings.ToUpper(source)] {
+			return nil, fmt.Errorf("mapped column %s is not a source column", source)
+		}
+	}
+
+	return plan, nil
+}
+
+// Destination column of a source column
+func (p *copyPlan) mapped(source string) (string, bool) {
+	for idx, column := range p.source {
+		if strings.EqualFold(column, source) {
+			return p.destination[idx], true
+		}
+	}
+
+	return "", false
+}
+
+func lookupFold(mapping map[string]string, key string) (string, bool) {
+	for name, value := range mapping {
+		if strings.EqualFold(name, key) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// Create the destination from the source columns when it's missing and create options are given
+func (c *Client) ensureCopyTable(ctx context.Context, dst string, plan *copyPlan, primaryKey []string, create *TableOptions) (created bool, err error) {
+	columns, _, err := c.liveTable(dst)
+	if err != nil {
+		return false, err
+	}
+	if len(columns) > 0 {
+		return false, nil
+	}
+
+	if create == nil {
+		return false, fmt.Errorf("%s doesn't exist, set CopyOptions.Create to create it", dst)
+	}
+
+	def := &TableDef{Name: dst}
+	for idx, source := range plan.source {
+		column := plan.types[strings.ToUpper(source)]
+		def.Columns = append(def.Columns, ColumnDef{Name: plan.destination[idx], Type: column.sqlType(), Nullable: column.Nullable})
+	}
+
+	for _, key := range primaryKey {
+		mapped, ok := plan.mapped(key)
+		if !ok {
+			return false, fmt.Errorf("primary key column %s is not copied, %s can't be created", key, dst)
+		}
+		def.PrimaryKey = append(def.PrimaryKey, mapped)
+	}
+
+	if _, err := c.CreateTableDef(ctx, def, *create); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Rows of a page read as a RowSource, with the source columns renamed to the destination columns
+type pageSource struct {
+	rows          []Row
+	sourceColumns []string
+	columns       []string
+	next          int
+}
+
+func (s *pageSource) Columns() []string {
+	return s.columns
+}
+
+func (s *pageSource) NextRow() ([]interface{}, error) {
+	if s.next >= len(s.rows) {
+		return nil, io.EOF
+	}
+
+	row := s.rows[s.next]
+	s.next++
+
+	values := make([]interface{}, len(s.sourceColumns))
+	for idx, column := range s.sourceColumns {
+		value, err := row.Value(column)
+		if err != nil {
+			return nil, err
+		}
+		values[idx] = value
+	}
+
+	return values, nil
+}
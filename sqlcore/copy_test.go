@@ -0,0 +1,121 @@
+package sqlcore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+)
+
+func TestCopyTable(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	defer func(previous func(string) ([]liveColumn, []string, error)) { liveTable = previous }(liveTable)
+	liveTable = func(table string) ([]liveColumn, []string, error) {
+		if table != "DEV.USERS" {
+			return nil, nil, nil
+		}
+		return []liveColumn{
+			{Column: "ID", DataType: "BIGINT"},
+			{Column: "NAME", DataType: "VARCHAR", Nullable: true},
+			{Column: "SECRET", DataType: "VARCHAR", Nullable: true},
+		}, []string{"ID"}, nil
+	}
+
+	source := NewClient("dev")
+	source.HTTPClient = &http.Client{Transport: &recordingTransport{respond: func(sqlText string) string {
+		switch {
+		case strings.Contains(sqlText, "(ID > 4)"):
+			return `[{"ID": 5, "NAME": "e"}]`
+		case strings.Contains(sqlText, "(ID > 2)"):
+			return `[{"ID": 3, "NAME": "c"}, {"ID": 4, "NAME": null}]`
+		default:
+			return `[{"ID": 1, "NAME": "a"}, {"ID": 2, "NAME": "b"}]`
+		}
+	}}}
+
+	transport := &recordingTransport{fail: "(3, "}
+	destination := NewClient("prod")
+	destination.HTTPClient = &http.Client{Transport: transport}
+
+	publicKey, _, _ := ed25519.GenerateKey(nil)
+	options := CopyOptions{
+		Destination: destination,
+		Columns:     map[string]string{"id": "ID", "NAME": "LABEL"},
+		Where:       "ID > 0",
+		PageSize:    2,
+		Create:      &TableOptions{AccessType: AccessPublicRead, PublicKey: publicKey},
+	}
+
+	result, err := source.CopyTable(context.Background(), "DEV.USERS", "PROD.USERS", options)
+	if err == nil || result.Copied != 2 || !result.Created || result.Cursor == "" {
+		t.Fatalf("expected the second page to fail, got %+v: %v", result, err)
+	}
+
+	if !strings.HasPrefix(transport.statements[0], "CREATE TABLE PROD.USERS (ID BIGINT NOT NULL, LABEL VARCHAR, PRIMARY KEY (ID)) WITH") {
+		t.Errorf("unexpected create statement %s", transport.statements[0])
+	}
+
+	transport.fail = ""
+	transport.statements = nil
+	options.Cursor = result.Cursor
+	result, err = source.CopyTable(context.Background(), "DEV.USERS", "PROD.USERS", options)
+	if err != nil || result.Copied != 3 || result.Created {
+		t.Fatalf("expected the copy to resume, got %+v: %v", result, err)
+	}
+
+	expected := []string{
+		"INSERT INTO PROD.USERS (ID, LABEL) VALUES (3, 'c'), (4, NULL)",
+		"INSERT INTO PROD.USERS (ID, LABEL) VALUES (5, 'e')",
+	}
+	if !reflect.DeepEqual(transport.statements, expected) {
+		t.Errorf("unexpected statements %q", transport.statements)
+	}
+
+	options.Cursor = ""
+	options.Columns = map[string]string{"MISSING": "X"}
+	if _, err := source.CopyTable(context.Background(), "DEV.USERS", "PROD.USERS", options); err == nil {
+		t.Error("expected an unknown mapped column to be rejected")
+	}
+}
+
+func TestCopyTableAcrossEnvironments(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+
+	// ETH.T exists in the source environment only
+	defer func(previous func(string) ([]liveColumn, []string, error)) { liveTable = previous }(liveTable)
+	liveTable = func(table string) ([]liveColumn, []string, error) {
+		return []liveColumn{{Column: "ID", DataType: "BIGINT"}}, []string{"ID"}, nil
+	}
+
+	source := NewClient("dev")
+	source.HTTPClient = &http.Client{Transport: &recordingTransport{respond: func(sqlText string) string {
+		if strings.Contains(sqlText, "WHERE") {
+			return "[]"
+		}
+		return `[{"ID": 1}]`
+	}}}
+
+	var described []string
+	transport := &recordingTransport{}
+	destination := NewClient("prod")
+	destination.HTTPClient = &http.Client{Transport: transport}
+	destination.DescribeTable = func(table string) ([]discovery.Column, []string, error) {
+		described = append(described, table)
+		return nil, nil, nil
+	}
+
+	publicKey, _, _ := ed25519.GenerateKey(nil)
+	options := CopyOptions{Destination: destination, Create: &TableOptions{AccessType: AccessPublicRead, PublicKey: publicKey}}
+	result, err := source.CopyTable(context.Background(), "ETH.T", "ETH.T", options)
+	if err != nil || !result.Created || result.Copied != 1 {
+		t.Fatalf("expected the destination to be created and filled, got %+v: %v", result, err)
+	}
+
+	if !reflect.DeepEqual(described, []string{"ETH.T"}) || !strings.HasPrefix(transport.statements[0], "CREATE TABLE ETH.T (ID BIGINT NOT NULL, PRIMARY KEY (ID))") {
+		t.Errorf("expected the destination to be looked up on its client, got %q and %q", described, transport.statements)
+	}
+}
@@ -3,17 +3,23 @@ package sqlcore
 import (
 	"crypto/ed25519"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 )
 
 // Create a new table on a given namespace.
-// accessType: can be public, permissioned or encrypted. Read more here https://docs.spaceandtime.io/docs/secure-your-table
+// accessType: can be public_read, public_append, public_write, permissioned or encrypted. Read more here https://docs.spaceandtime.io/docs/secure-your-table
 func CreateTable(sqlText, accessType, originApp string, biscuitArray []string, publicKey ed25519.PublicKey) (errMsg string, status bool) {
-	sqlTextWithConfiguration := fmt.Sprintf("%s WITH \"public_key=%x,access_type=%s\"", sqlText, publicKey, accessType)
+	return CreateTableWithOptions(sqlText, originApp, biscuitArray, TableOptions{AccessType: AccessType(accessType), PublicKey: publicKey})
+}
+
+// Create a new table with validated options. Options are checked before anything is sent
+func CreateTableWithOptions(sqlText, originApp string, biscuitArray []string, options TableOptions) (errMsg string, status bool) {
+	if err := options.Validate(); err != nil {
+		return err.Error(), false
+	}
 
-	return DDL(sqlTextWithConfiguration, originApp, biscuitArray)
+	return DDL(sqlText+" "+options.withClause(), originApp, biscuitArray)
 }
 
 // DDL queries for ALTER and DROP
@@ -39,6 +45,7 @@ func DDL(sqlText, originApp string, biscuitArray []string) (errMsg string, statu
 		return string(body), false
 	}
 
+	invalidateDiscovery(sqlText)
 	return "", true
 
 }
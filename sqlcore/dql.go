@@ -7,9 +7,9 @@ import (
 )
 
 // Run all DQL queries
-// rowCount is optional
+// rowCount is optional. If rowCount is 0, then fetches all data without limit
 func DQL(sqlText, originApp string, biscuitArray, resources []string, rowCount int) (data []byte, errMsg string, status bool) {
-	request, err := createQueryExecutionRequest(sqlText, originApp, biscuitArray, resources)
+	request, err := createQueryExecutionRequest(sqlText, originApp, biscuitArray, resources, rowCount)
 	if err != nil {
 		return data, err.Error(), false
 	}
@@ -33,12 +33,21 @@ func DQL(sqlText, originApp string, biscuitArray, resources []string, rowCount i
 	return body, "", true
 }
 
-func createQueryExecutionRequest(sqlText, originApp string, biscuitArray, resources []string) (request *http.Request, err error) {
-	postBody, _ := json.Marshal(map[string]interface{}{
+func createQueryExecutionRequest(sqlText, originApp string, biscuitArray, resources []string, rowCount int) (request *http.Request, err error) {
+	return createRequest("dql", originApp, queryExecutionBody(sqlText, biscuitArray, resources, rowCount))
+}
+
+func queryExecutionBody(sqlText string, biscuitArray, resources []string, rowCount int) (postBody []byte) {
+	body := map[string]interface{}{
 		"biscuits":  biscuitArray,
 		"resources": resources,
 		"sqlText":   sqlText,
-	})
+	}
+
+	if rowCount > 0 {
+		body["rowCount"] = rowCount
+	}
 
-	return createRequest("dql", originApp, postBody)
+	postBody, _ = json.Marshal(body)
+	return postBody
 }
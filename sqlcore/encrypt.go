@@ -0,0 +1,504 @@
+package sqlcore
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Prefix of encrypted column values. Encrypted columns must be VARCHAR
+const encryptedPrefix = "sxtenc1:"
+
+// Encryption modes stored in encrypted values
+const (
+	modeRandom        byte = 1
+	modeDeterministic byte = 2
+)
+
+const dataKeySize = 32
+
+// Wraps and unwraps column data keys, e.g. with a KMS key. Data keys never leave the process unwrapped
+type KeyProvider interface {
+	WrapKey(ctx context.Context, dataKey []byte) (wrapped []byte, err error)
+	UnwrapKey(ctx context.Context, wrapped []byte) (dataKey []byte, err error)
+}
+
+// Key provider wrapping data keys with a local AES-256-GCM key
+type StaticKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// Key provider for a 32 byte key
+func NewStaticKeyProvider(key []byte) (*StaticKeyProvider, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key has %d bytes, expected 32", len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaticKeyProvider{aead: aead}, nil
+}
+
+func (p *StaticKeyProvider) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return p.aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (p *StaticKeyProvider) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < p.aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+
+	nonce, sealed := wrapped[:p.aead.NonceSize()], wrapped[p.aead.NonceSize():]
+	return p.aead.Open(nil, nonce, sealed, nil)
+}
+
+// Encryption settings of a column
+type EncryptedColumn struct {
+	Name string
+
+	// Deterministic columns encrypt equal values to equal ciphertexts, so they can be matched with =, <> and IN.
+	// This reveals which rows hold equal values
+	Deterministic bool
+
+	// WrappedKey is the column data key from ColumnEncryption.GenerateDataKey, required for deterministic columns.
+	// Random columns without it get a new data key in every process. Values can always be decrypted,
+	// as each value carries its wrapped key
+	WrappedKey []byte
+}
+
+type columnKey struct {
+	settings EncryptedColumn
+	aead     cipher.AEAD
+	nonceKey []byte
+	wrapped  []byte
+}
+
+// Client side envelope encryption of table columns. Values are encrypted with AES-256-GCM by a data key per
+// column, wrapped by the key provider. Set it as Client.Encryption to encrypt the values of BulkInsert and Upsert
+// and decrypt query results
+type ColumnEncryption struct {
+	Provider KeyProvider
+
+	mutex  sync.Mutex
+	tables map[string]map[string]*columnKey
+	keys   map[string]cipher.AEAD
+}
+
+func NewColumnEncryption(provider KeyProvider) *ColumnEncryption {
+	return &ColumnEncryption{
+		Provider: provider,
+		tables:   map[string]map[string]*columnKey{},
+		keys:     map[string]cipher.AEAD{},
+	}
+}
+
+// Generate a data key and return it wrapped by the provider, to be stored in EncryptedColumn.WrappedKey
+func (e *ColumnEncryption) GenerateDataKey(ctx context.Context) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	return e.Provider.WrapKey(ctx, dataKey)
+}
+
+// Encrypt columns of a table. Table and column names are matched case insensitively
+func (e *ColumnEncryption) AddTable(table string, columns ...EncryptedColumn) error {
+	if !isTableName(table) {
+		return fmt.Errorf("invalid table name %q", table)
+	}
+
+	keys := map[string]*columnKey{}
+	for _, column := range columns {
+		if column.Name == "" {
+			return errors.New("encrypted column has no name")
+		}
+
+		if column.Deterministic && len(column.WrappedKey) == 0 {
+			return fmt.Errorf("deterministic column %s needs a WrappedKey", column.Name)
+		}
+
+		keys[strings.ToUpper(column.Name)] = &columnKey{settings: column}
+	}
+
+	e.mutex.Lock()
+	e.tables[strings.ToUpper(table)] = keys
+	e.mutex.Unlock()
+
+	return nil
+}
+
+// Encryption settings of a column, false when it isn't encrypted
+func (e *ColumnEncryption) Column(table, column string) (EncryptedColumn, bool) {
+	key := e.lookup(table, column)
+	if key == nil {
+		return EncryptedColumn{}, false
+	}
+
+	return key.settings, true
+}
+
+// Encrypted columns of a table
+func (e *ColumnEncryption) Columns(table string) (columns []EncryptedColumn) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, key := range e.tables[strings.ToUpper(table)] {
+		columns = append(columns, key.settings)
+	}
+
+	return columns
+}
+
+func (e *ColumnEncryption) lookup(table, column string) *columnKey {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.tables[strings.ToUpper(table)][strings.ToUpper(column)]
+}
+
+// Encrypt a value for a column. Values of columns that are not encrypted and NULLs are returned unchanged.
+// Values are the types FormatLiteral accepts and decrypt to the value decoded from a DQL response:
+// strings, json.Number for numbers, bools, timestamps as strings and []byte as base64 strings
+func (e *ColumnEncryption) EncryptValue(ctx context.Context, table, column string, value interface{}) (interface{}, error) {
+	key := e.lookup(table, column)
+	if key == nil {
+		return value, nil
+	}
+
+	plaintext, err := plainValue(value)
+	if err != nil || plaintext == nil {
+		return value, err
+	}
+
+	if err := e.load(ctx, key); err != nil {
+		return nil, err
+	}
+
+	// The nonce of deterministic values depends on the location too, so columns sharing a key don't reveal equal values
+	additionalData := associatedData(table, column, key.wrapped)
+	mode := modeRandom
+	nonce := make([]byte, key.aead.NonceSize())
+	if key.settings.Deterministic {
+		mode = modeDeterministic
+		mac := hmac.New(sha256.New, key.nonceKey)
+		mac.Write(additionalData)
+		mac.Write(plaintext)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	var envelope bytes.Buffer
+	envelope.WriteByte(mode)
+	binary.Write(&envelope, binary.BigEndian, uint16(len(key.wrapped)))
+	envelope.Write(key.wrapped)
+	envelope.Write(nonce)
+	envelope.Write(key.aead.Seal(nil, nonce, plaintext, additionalData))
+
+	return encryptedPrefix + base64.RawStdEncoding.EncodeToString(envelope.Bytes()), nil
+}
+
+// Unwrap, or generate, the data key of a column on first use
+func (e *ColumnEncryption) load(ctx context.Context, key *columnKey) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if key.aead != nil {
+		return nil
+	}
+
+	wrapped := key.settings.WrappedKey
+	var dataKey []byte
+	var err error
+	if len(wrapped) == 0 {
+		dataKey = make([]byte, dataKeySize)
+		if _, err := rand.Read(dataKey); err != nil {
+			return err
+		}
+		if wrapped, err = e.Provider.WrapKey(ctx, dataKey); err != nil {
+			return err
+		}
+	} else if dataKey, err = e.Provider.UnwrapKey(ctx, wrapped); err != nil {
+		return fmt.Errorf("unwrap data key of column %s: %w", key.settings.Name, err)
+	}
+
+	if key.aead, err = newAEAD(dataKey); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte("sxt deterministic nonce"))
+	key.nonceKey = mac.Sum(nil)
+	key.wrapped = wrapped
+	e.keys[string(wrapped)] = key.aead
+
+	return nil
+}
+
+// Associated data of a value: its table, column and wrapped data key. A value moved to another column
+// or table, or re-wrapped under another key, fails to decrypt
+func associatedData(table, column string, wrapped []byte) []byte {
+	var data bytes.Buffer
+	data.WriteString(encryptedPrefix)
+	data.WriteString(strings.ToUpper(table))
+	data.WriteByte(0)
+	data.WriteString(strings.ToUpper(column))
+	data.WriteByte(0)
+	data.Write(wrapped)
+
+	return data.Bytes()
+}
+
+// Decrypt a value read from a column of a table. Values that are not encrypted are returned unchanged
+func (e *ColumnEncryption) DecryptValue(ctx context.Context, table, column string, value interface{}) (interface{}, error) {
+	text, ok := value.(string)
+	if !ok || !strings.HasPrefix(text, encryptedPrefix) {
+		return value, nil
+	}
+
+	envelope, err := base64.RawStdEncoding.DecodeString(text[len(encryptedPrefix):])
+	if err != nil || len(envelope) < 3 || (envelope[0] != modeRandom && envelope[0] != modeDeterministic) {
+		return nil, errors.New("malformed encrypted value")
+	}
+
+	wrappedSize := int(binary.BigEndian.Uint16(envelope[1:3]))
+	if len(envelope) < 3+wrappedSize {
+		return nil, errors.New("malformed encrypted value")
+	}
+	wrapped, sealed := envelope[3:3+wrappedSize], envelope[3+wrappedSize:]
+
+	aead, err := e.unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted value")
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], associatedData(table, column, wrapped))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt value of %s.%s: %w", strings.ToUpper(table), strings.ToUpper(column), err)
+	}
+
+	return decodeValue(plaintext)
+}
+
+// Data keys are unwrapped once per process
+func (e *ColumnEncryption) unwrap(ctx context.Context, wrapped []byte) (cipher.AEAD, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if aead, ok := e.keys[string(wrapped)]; ok {
+		return aead, nil
+	}
+
+	dataKey, err := e.Provider.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	e.keys[string(wrapped)] = aead
+	return aead, nil
+}
+
+// Decode a DQL response body like DecodeResultSet, decrypting encrypted values.
+// tables are the tables the query read, every table with encrypted columns when none are given
+func (e *ColumnEncryption) DecodeResultSet(ctx context.Context, data []byte, tables ...string) (*ResultSet, error) {
+	reader := newRowReader(bytes.NewReader(data))
+	reader.decrypt = e.decrypter(ctx, tables)
+	resultSet := &ResultSet{columns: reader.columns}
+
+	for {
+		row, ok, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return resultSet, nil
+		}
+
+		resultSet.Rows = append(resultSet.Rows, row)
+	}
+}
+
+// Decrypt the values of result columns. A column named like an encrypted column of the tables read is decrypted
+// as that column. Other names, e.g. aliases, are tried against every encrypted column of the tables
+func (e *ColumnEncryption) decrypter(ctx context.Context, tables []string) func(string, interface{}) (interface{}, error) {
+	return func(column string, value interface{}) (interface{}, error) {
+		if text, ok := value.(string); !ok || !strings.HasPrefix(text, encryptedPrefix) {
+			return value, nil
+		}
+
+		var named, others [][2]string
+		for _, location := range e.locations(tables) {
+			if strings.EqualFold(location[1], column) {
+				named = append(named, location)
+			} else {
+				others = append(others, location)
+			}
+		}
+
+		candidates := named
+		if len(candidates) == 0 {
+			candidates = others
+		}
+
+		err := fmt.Errorf("column %s is not an encrypted column of the tables read", column)
+		for _, location := range candidates {
+			var decrypted interface{}
+			if decrypted, err = e.DecryptValue(ctx, location[0], location[1], value); err == nil {
+				return decrypted, nil
+			}
+		}
+
+		return nil, err
+	}
+}
+
+// Table and column of the encrypted columns of tables, of every table when none are given
+func (e *ColumnEncryption) locations(tables []string) (locations [][2]string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if len(tables) == 0 {
+		for table := range e.tables {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+	}
+
+	for _, table := range tables {
+		table = strings.ToUpper(table)
+		for column := range e.tables[table] {
+			locations = append(locations, [2]string{table, column})
+		}
+	}
+
+	return locations
+}
+
+// Wrap a row source of a table so its encrypted columns are encrypted as rows are read
+func (e *ColumnEncryption) source(ctx context.Context, table string, source RowSource) RowSource {
+	keys := make([]*columnKey, len(source.Columns()))
+	encrypted := false
+	for idx, column := range source.Columns() {
+		keys[idx] = e.lookup(table, column)
+		encrypted = encrypted || keys[idx] != nil
+	}
+
+	if !encrypted {
+		return source
+	}
+
+	return &encryptingSource{RowSource: source, ctx: ctx, encryption: e, table: table, keys: keys}
+}
+
+type encryptingSource struct {
+	RowSource
+
+	ctx        context.Context
+	encryption *ColumnEncryption
+	table      string
+	keys       []*columnKey
+}
+
+func (s *encryptingSource) NextRow() ([]interface{}, error) {
+	values, err := s.RowSource.NextRow()
+	if err != nil {
+		return values, err
+	}
+
+	// Sources may reuse their slice
+	encrypted := make([]interface{}, len(values))
+	for idx, value := range values {
+		encrypted[idx] = value
+		if idx < len(s.keys) && s.keys[idx] != nil {
+			if encrypted[idx], err = s.encryption.EncryptValue(s.ctx, s.table, s.keys[idx].settings.Name, value); err != nil {
+				return nil, fmt.Errorf("column %s: %w", s.keys[idx].settings.Name, err)
+			}
+		}
+	}
+
+	return encrypted, nil
+}
+
+// Key columns are matched by value, so they can only be encrypted deterministically
+func (e *ColumnEncryption) checkKeys(table string, keyColumns []string) error {
+	for _, column := range keyColumns {
+		if settings, ok := e.Column(table, column); ok && !settings.Deterministic {
+			return fmt.Errorf("key column %s is encrypted randomly, it can't be matched", column)
+		}
+	}
+
+	return nil
+}
+
+// JSON text of a value, as decodeValue reads it back. nil for NULL.
+// The literal rendered by FormatLiteral is reused, so the accepted types and the canonical form
+// of deterministic values are the same as in SQL text
+func plainValue(value interface{}) ([]byte, error) {
+	literal, err := FormatLiteral(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case literal == "NULL":
+		return nil, nil
+	case literal == "TRUE" || literal == "FALSE":
+		return []byte(strings.ToLower(literal)), nil
+	case strings.HasPrefix(literal, "'"):
+		return json.Marshal(strings.ReplaceAll(literal[1:len(literal)-1], "''", "'"))
+	case strings.HasPrefix(literal, "TIMESTAMP '"):
+		return json.Marshal(literal[len("TIMESTAMP '") : len(literal)-1])
+	case strings.HasPrefix(literal, "X'"):
+		raw, err := hex.DecodeString(literal[2 : len(literal)-1])
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(raw)
+	}
+
+	if !numberPattern.MatchString(literal) {
+		return nil, fmt.Errorf("can't encrypt %s", literal)
+	}
+
+	return []byte(literal), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
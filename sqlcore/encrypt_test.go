@@ -0,0 +1,139 @@
+package sqlcore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEncryption(t *testing.T) *ColumnEncryption {
+	provider, err := NewStaticKeyProvider([]byte(strings.Repeat("k", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryption := NewColumnEncryption(provider)
+	wrappedKey, err := encryption.GenerateDataKey(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := encryption.AddTable("ETH.USERS", EncryptedColumn{Name: "EMAIL", Deterministic: true, WrappedKey: wrappedKey}, EncryptedColumn{Name: "ssn"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return encryption
+}
+
+func TestEncryptValue(t *testing.T) {
+	ctx := context.Background()
+	encryption := testEncryption(t)
+
+	if err := encryption.AddTable("ETH.T", EncryptedColumn{Name: "A", Deterministic: true}); err == nil {
+		t.Error("expected deterministic columns without a key to be rejected")
+	}
+
+	first, _ := encryption.EncryptValue(ctx, "eth.users", "email", "a@b.c")
+	second, _ := encryption.EncryptValue(ctx, "ETH.USERS", "EMAIL", "a@b.c")
+	if first != second || !strings.HasPrefix(first.(string), encryptedPrefix) {
+		t.Errorf("expected equal deterministic ciphertexts, got %v and %v", first, second)
+	}
+
+	first, _ = encryption.EncryptValue(ctx, "ETH.USERS", "SSN", "123")
+	second, _ = encryption.EncryptValue(ctx, "ETH.USERS", "SSN", "123")
+	if first == second {
+		t.Error("expected random ciphertexts to differ")
+	}
+
+	if value, _ := encryption.EncryptValue(ctx, "ETH.USERS", "ID", 5); value != 5 {
+		t.Errorf("expected plain columns to be unchanged, got %v", value)
+	}
+
+	if value, _ := encryption.EncryptValue(ctx, "ETH.USERS", "SSN", nil); value != nil {
+		t.Errorf("expected NULL to stay NULL, got %v", value)
+	}
+
+	created := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	for value, expected := range map[interface{}]interface{}{
+		"it's":  "it's",
+		42:      json.Number("42"),
+		-1.5:    json.Number("-1.5"),
+		true:    true,
+		created: "2023-03-01 10:00:00",
+	} {
+		encrypted, err := encryption.EncryptValue(ctx, "ETH.USERS", "SSN", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := encryption.DecryptValue(ctx, "ETH.USERS", "SSN", encrypted)
+		if err != nil || decrypted != expected {
+			t.Errorf("%v: decrypted to %v (%T): %v", value, decrypted, decrypted, err)
+		}
+	}
+
+	encrypted, _ := encryption.EncryptValue(ctx, "ETH.USERS", "SSN", "x")
+	envelope, _ := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(encrypted.(string), encryptedPrefix))
+	envelope[len(envelope)-1] ^= 1
+	if _, err := encryption.DecryptValue(ctx, "ETH.USERS", "SSN", encryptedPrefix+base64.RawStdEncoding.EncodeToString(envelope)); err == nil {
+		t.Error("expected tampered values to fail")
+	}
+
+	// Values are bound to their table and column
+	if _, err := encryption.DecryptValue(ctx, "ETH.USERS", "EMAIL", encrypted); err == nil {
+		t.Error("expected a value moved to another column to fail")
+	}
+	if _, err := encryption.DecryptValue(ctx, "ETH.ADMINS", "SSN", encrypted); err == nil {
+		t.Error("expected a value moved to another table to fail")
+	}
+}
+
+func TestEncryptedBulkInsertAndQuery(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	ctx := context.Background()
+
+	var stored, ssn string
+	transport := &recordingTransport{respond: func(sqlText string) string {
+		if ssn != "" {
+			return `[{"ID": 1, "EMAIL": "` + stored + `", "SSN": "` + ssn + `"}]`
+		}
+		return `[{"ID": 1, "EMAIL": "` + stored + `", "SSN": null}]`
+	}}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+	client.Encryption = testEncryption(t)
+
+	rows := []map[string]interface{}{{"ID": 1, "EMAIL": "a@b.c", "SSN": nil}}
+	if _, err := client.BulkInsert(ctx, "ETH.USERS", rows, BulkOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	email, _ := client.Encryption.EncryptValue(ctx, "ETH.USERS", "EMAIL", "a@b.c")
+	stored = email.(string)
+	if transport.statements[0] != "INSERT INTO ETH.USERS (EMAIL, ID, SSN) VALUES ('"+stored+"', 1, NULL)" {
+		t.Errorf("unexpected statement %s", transport.statements[0])
+	}
+
+	resultSet, err := client.Query(ctx, "SELECT * FROM ETH.USERS", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value, _ := resultSet.Rows[0].String("EMAIL"); value != "a@b.c" {
+		t.Errorf("expected the email to be decrypted, got %s", value)
+	}
+
+	// The email ciphertext copied into the SSN column is detected
+	ssn = stored
+	if _, err := client.Query(ctx, "SELECT * FROM ETH.USERS", nil); err == nil {
+		t.Error("expected a value moved to another column to fail")
+	}
+
+	if _, err := client.UpsertWithOptions(ctx, "ETH.USERS", rows, UpsertOptions{KeyColumns: []string{"SSN"}}); err == nil {
+		t.Error("expected randomly encrypted keys to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+package sqlcore
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Outcome of a statement run by Client.Exec
+type ExecResult struct {
+	SQL string
+
+	// Kind is sqlparser.KindDDL, KindDML or KindDQL and Operation the biscuit operation, e.g. dml_insert
+	Kind      string
+	Operation string
+	Resources []string
+
+	// Rows of a query
+	Rows *ResultSet
+
+	// RowsAffected by a DML statement, when the gateway reported it
+	RowsAffected int
+	Counted      bool
+}
+
+// Settings for Client.ExecScript
+type ScriptOptions struct {
+	// ContinueOnError runs the remaining statements after a failure instead of stopping
+	ContinueOnError bool
+}
+
+// Outcome of one statement of a script
+type StatementResult struct {
+	Index  int
+	Pos    sqlparser.Position
+	Result *ExecResult
+	Err    error
+}
+
+// Run any single statement. It is classified by the SQL analyzer, sent to the ddl, dml or dql
+// endpoint and its resources are taken from the tables it references
+func (c *Client) Exec(ctx context.Context, sqlText string) (*ExecResult, error) {
+	statement, err := sqlparser.Analyze(sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExecResult{
+		SQL:       sqlText,
+		Kind:      statement.Kind,
+		Operation: statement.Operation,
+		Resources: statement.Resources(),
+	}
+
+	switch statement.Kind {
+	case sqlparser.KindDDL:
+		err = c.DDL(ctx, sqlText, result.Resources)
+
+	case sqlparser.KindDML:
+		var data []byte
+		data, err = c.dml(ctx, sqlText, result.Resources)
+		if err == nil {
+			inserted, updated, counted := affectedRows(data)
+			result.RowsAffected, result.Counted = inserted+updated, counted
+		}
+
+	case sqlparser.KindDQL:
+		result.Rows, err = c.Query(ctx, sqlText, result.Resources)
+
+	default:
+		err = fmt.Errorf("unsupported statement kind %s", statement.Kind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Run the semicolon separated statements of a script in order.
+// Every statement run gets a result. The returned error is the first failure, with the statement position,
+// also when ContinueOnError is set
+func (c *Client) ExecScript(ctx context.Context, script string, options ScriptOptions) (results []StatementResult, err error) {
+	statements, err := sqlparser.Split(script)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, statement := range statements {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		result, execErr := c.Exec(ctx, statement.SQL)
+		results = append(results, StatementResult{Index: idx, Pos: statement.Pos, Result: result, Err: execErr})
+
+		if execErr != nil {
+			if err == nil {
+				err = fmt.Errorf("statement %d at %s: %w", idx+1, statement.Pos, execErr)
+			}
+			if !options.ContinueOnError {
+				return results, err
+			}
+		}
+	}
+
+	return results, err
+}
+
+// Run a script file, see ExecScript
+func (c *Client) ExecScriptFile(ctx context.Context, filename string, options ScriptOptions) ([]StatementResult, error) {
+	script, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ExecScript(ctx, string(script), options)
+}
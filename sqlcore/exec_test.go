@@ -0,0 +1,51 @@
+package sqlcore
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecScript(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	transport := &recordingTransport{fail: "BROKEN", respond: func(sqlText string) string {
+		if strings.HasPrefix(sqlText, "INSERT") {
+			return `[{"UPDATED": 2}]`
+		}
+		if strings.HasPrefix(sqlText, "SELECT") {
+			return `[{"ID": 1}, {"ID": 2}]`
+		}
+		return "[]"
+	}}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	script := `CREATE TABLE ETH.T (ID INT PRIMARY KEY);
+INSERT INTO ETH.T VALUES (1), (2);
+INSERT INTO ETH.BROKEN VALUES (3);
+SELECT * FROM ETH.T;`
+
+	results, err := client.ExecScript(context.Background(), script, ScriptOptions{})
+	if err == nil || len(results) != 3 || !strings.Contains(err.Error(), "statement 3 at 3:1") {
+		t.Fatalf("expected to stop at statement 3, got %d results and %v", len(results), err)
+	}
+
+	if !reflect.DeepEqual(transport.endpoints, []string{"/sql/ddl", "/sql/dml", "/sql/dml"}) {
+		t.Errorf("unexpected endpoints %v", transport.endpoints)
+	}
+
+	if insert := results[1].Result; !insert.Counted || insert.RowsAffected != 2 || insert.Resources[0] != "ETH.T" {
+		t.Errorf("unexpected insert result %+v", insert)
+	}
+
+	results, err = client.ExecScript(context.Background(), script, ScriptOptions{ContinueOnError: true})
+	if err == nil || len(results) != 4 {
+		t.Fatalf("expected all statements to run, got %d results and %v", len(results), err)
+	}
+
+	if rows := results[3].Result.Rows; rows == nil || len(rows.Rows) != 2 {
+		t.Errorf("expected query rows, got %+v", results[3].Result)
+	}
+}
@@ -0,0 +1,322 @@
+package sqlcore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// DDL rebuilt from the discovery metadata of a schema, see Client.ExportSchemaDDL
+type SchemaExport struct {
+	Schema string
+
+	// Objects are in dependency order: tables after the tables their foreign keys reference, then views
+	Objects []ExportedObject
+}
+
+// A table or view of an export with the statements creating it
+type ExportedObject struct {
+	// Name is SCHEMA.TABLE or SCHEMA.VIEW
+	Name string
+
+	// Kind is TABLE or VIEW
+	Kind string
+
+	// Statements without semicolons: CREATE TABLE and CREATE INDEX, or CREATE VIEW
+	Statements []string
+
+	// Notes are written as comments, e.g. for parameterized views which have no DDL
+	Notes []string
+}
+
+// Rebuild the CREATE TABLE, CREATE INDEX and CREATE VIEW statements of a schema from the discovery APIs:
+// columns with their types and nullability, primary keys, indexes and foreign keys. Table options such as
+// access types are not discovered and left out. Parameterized views are noted, as they have no DDL
+func (c *Client) ExportSchemaDDL(ctx context.Context, schema string) (*SchemaExport, error) {
+	schema = strings.ToUpper(schema)
+	tables, errMsg, status := discovery.ListTables(schema, discovery.ScopeAll, "")
+	if !status {
+		return nil, fmt.Errorf("unable to discover the tables of %s: %s", schema, errMsg)
+	}
+
+	objects := map[string]*ExportedObject{}
+	dependencies := map[string][]string{}
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		object, references, err := exportTable(schema, table.Table)
+		if err != nil {
+			return nil, err
+		}
+		objects[object.Name] = object
+		dependencies[object.Name] = references
+	}
+
+	export := &SchemaExport{Schema: schema}
+	for _, name := range dependencyOrder(objects, dependencies) {
+		export.Objects = append(export.Objects, *objects[name])
+	}
+
+	views, err := listViews("")
+	if err != nil {
+		return nil, err
+	}
+
+	viewObjects := map[string]*ExportedObject{}
+	viewDependencies := map[string][]string{}
+	for _, view := range views {
+		viewSchema, _, _ := strings.Cut(strings.ToUpper(view.Name), ".")
+		if viewSchema != schema {
+			continue
+		}
+
+		object, references := exportView(view)
+		viewObjects[object.Name] = object
+		viewDependencies[object.Name] = references
+	}
+
+	for _, name := range dependencyOrder(viewObjects, viewDependencies) {
+		export.Objects = append(export.Objects, *viewObjects[name])
+	}
+
+	return export, nil
+}
+
+// CREATE TABLE and CREATE INDEX statements of a table named as stored, and the tables its foreign keys reference
+func exportTable(schema, name string) (object *ExportedObject, references []string, err error) {
+	table := exportedName(schema, name)
+	columns, primaryKey, err := discoverStoredTable(schema, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("%s has no columns", table)
+	}
+
+	definitions := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		definitions = append(definitions, ColumnDef{Name: quoteStoredIdentifier(column.Column), Type: column.sqlType(), Nullable: column.Nullable}.definition())
+	}
+
+	if len(primaryKey) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+quoteIdentifiers(primaryKey)+")")
+	}
+
+	// Discovery doesn't name constraints, so each foreign key column is its own constraint.
+	// Entries whose foreign key side is another table are references to this table, not its keys
+	var referenced []string
+	seen := map[string]bool{}
+	for _, column := range columns {
+		keys, errMsg, status := discovery.ListForeignKeyReferences(schema, name, column.Column)
+		if !status {
+			return nil, nil, fmt.Errorf("unable to discover the foreign keys of %s: %s", table, errMsg)
+		}
+
+		for _, key := range keys {
+			if key.ForeignKeyColumn == "" {
+				key.ForeignKeyColumn = column.Column
+			}
+			if key.ForeignKeyTable != "" && (!strings.EqualFold(key.ForeignKeySchema, schema) || key.ForeignKeyTable != name) {
+				continue
+			}
+			if !strings.EqualFold(key.ForeignKeyColumn, column.Column) {
+				continue
+			}
+
+			target := exportedName(strings.ToUpper(key.PrimaryKeySchema), key.PrimaryKeyTable)
+			definition := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", quoteStoredIdentifier(key.ForeignKeyColumn), target, quoteStoredIdentifier(key.PrimaryKeyColumn))
+			if seen[definition] {
+				continue
+			}
+			seen[definition] = true
+			definitions = append(definitions, definition)
+
+			if !isKey(referenced, target) {
+				referenced = append(referenced, target)
+			}
+		}
+	}
+
+	object = &ExportedObject{Name: table, Kind: "TABLE"}
+	object.Statements = append(object.Statements, fmt.Sprintf("CREATE TABLE %s (%s)", table, strings.Join(definitions, ", ")))
+
+	indexes, err := exportIndexes(schema, name, primaryKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	object.Statements = append(object.Statements, indexes...)
+
+	return object, referenced, nil
+}
+
+// Name of a table as written in the export, quoted when it isn't stored upper case
+func exportedName(schema, table string) string {
+	return schema + "." + quoteStoredIdentifier(table)
+}
+
+// CREATE INDEX statements of a table, leaving out the primary key index
+func exportIndexes(schema, table string, primaryKey []string) (statements []string, err error) {
+	entries, errMsg, status := discovery.ListTableIndex(schema, table)
+	if !status {
+		return nil, fmt.Errorf("unable to discover the indexes of %s.%s: %s", schema, table, errMsg)
+	}
+
+	// Entries are one per indexed column
+	var names []string
+	indexes := map[string][]discovery.Index{}
+	for _, entry := range entries {
+		if _, ok := indexes[entry.Name]; !ok {
+			names = append(names, entry.Name)
+		}
+		indexes[entry.Name] = append(indexes[entry.Name], entry)
+	}
+
+	for _, name := range names {
+		index := indexes[name]
+		sort.SliceStable(index, func(i, j int) bool { return index[i].Ordinal < index[j].Ordinal })
+
+		columns := make([]string, len(index))
+		for idx, entry := range index {
+			columns[idx] = entry.Column
+		}
+
+		if index[0].Unique && sameColumns(columns, primaryKey) {
+			continue
+		}
+
+		unique := ""
+		if index[0].Unique {
+			unique = "UNIQUE "
+		}
+		statements = append(statements, fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, quoteStoredIdentifier(name), exportedName(schema, table), quoteIdentifiers(columns)))
+	}
+
+	return statements, nil
+}
+
+// CREATE VIEW statement of a view, and the tables and views it reads
+func exportView(view View) (object *ExportedObject, references []string) {
+	name := strings.ToUpper(view.Name)
+	object = &ExportedObject{Name: name, Kind: "VIEW"}
+	sqlText := strings.TrimRight(strings.TrimSpace(view.SQL), ";")
+
+	if statement, err := sqlparser.Analyze(sqlText); err == nil {
+		references = statement.Resources()
+	}
+
+	if len(view.Parameters) > 0 {
+		parameters := make([]string, len(view.Parameters))
+		for idx, parameter := range view.Parameters {
+			parameters[idx] = parameter.Name + " " + parameter.Type
+		}
+		object.Notes = append(object.Notes,
+			fmt.Sprintf("parameterized view %s (%s), create it with Client.CreateView:", name, strings.Join(parameters, ", ")),
+			sqlText)
+		return object, references
+	}
+
+	object.Statements = []string{fmt.Sprintf("CREATE VIEW %s AS %s", name, sqlText)}
+	return object, references
+}
+
+// Names sorted so that objects come after the objects they depend on. Ties and cycles keep name order
+func dependencyOrder(objects map[string]*ExportedObject, dependencies map[string][]string) (order []string) {
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	done := map[string]bool{}
+	visiting := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if done[name] || visiting[name] {
+			return
+		}
+
+		visiting[name] = true
+		for _, dependency := range dependencies[name] {
+			if _, ok := objects[dependency]; ok && dependency != name {
+				visit(dependency)
+			}
+		}
+		visiting[name] = false
+
+		done[name] = true
+		order = append(order, name)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+
+	return order
+}
+
+// Identifiers of names as stored, see quoteStoredIdentifier
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for idx, name := range names {
+		quoted[idx] = quoteStoredIdentifier(name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// SQL text of an object: notes as comments, then its statements
+func (o *ExportedObject) Script() string {
+	var builder strings.Builder
+	for _, note := range o.Notes {
+		for _, line := range strings.Split(note, "\n") {
+			builder.WriteString("-- " + line + "\n")
+		}
+	}
+
+	for _, statement := range o.Statements {
+		builder.WriteString(statement + ";\n")
+	}
+
+	return builder.String()
+}
+
+// Write every object as one script, in dependency order
+func (e *SchemaExport) WriteScript(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "-- Schema %s\n", e.Schema); err != nil {
+		return err
+	}
+
+	for _, object := range e.Objects {
+		if _, err := io.WriteString(w, "\n"+object.Script()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write one file per object in dir, named <order>_<SCHEMA>.<NAME>.sql so sorting the names keeps dependency order
+func (e *SchemaExport) WriteFiles(dir string) (filenames []string, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	for idx, object := range e.Objects {
+		filename := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", idx+1, strings.ReplaceAll(object.Name, `"`, "")))
+		if err := os.WriteFile(filename, []byte(object.Script()), 0o644); err != nil {
+			return filenames, err
+		}
+		filenames = append(filenames, filename)
+	}
+
+	return filenames, nil
+}
@@ -0,0 +1,134 @@
+package sqlcore
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Discovery responses keyed by path and query
+var exportResponses = map[string]string{
+	"/discover/table?schema=ETH&scope=ALL": `[{"schema": "ETH", "table": "TX"}, {"schema": "ETH", "table": "BLOCKS"}]`,
+
+	"/discover/table/column?schema=ETH&table=BLOCKS":     `[{"column": "ID", "dataType": "BIGINT"}, {"column": "HASH", "dataType": "VARCHAR", "nullable": true}]`,
+	"/discover/table/primarykey?schema=ETH&table=BLOCKS": `[{"column": "ID"}]`,
+	"/discover/table/index?schema=ETH&table=BLOCKS":      `[{"name": "BLOCKS_PK", "column": "ID", "unique": true, "ordinal": 1}]`,
+
+	"/discover/table/column?schema=ETH&table=TX":     `[{"column": "ID", "dataType": "BIGINT"}, {"column": "BLOCK_ID", "dataType": "BIGINT"}, {"column": "VALUE", "dataType": "NUMERIC", "precision": 18, "scale": 2, "nullable": true}, {"column": "PREV_BLOCK_ID", "dataType": "BIGINT", "nullable": true}]`,
+	"/discover/table/primarykey?schema=ETH&table=TX": `[{"column": "ID"}]`,
+	"/discover/table/index?schema=ETH&table=TX":      `[{"name": "TX_BLOCK", "column": "BLOCK_ID", "unique": false, "ordinal": 1}]`,
+
+	"/discover/refs/foreignkey?column=BLOCK_ID&schema=ETH&table=TX":      `[{"primaryKeySchema": "ETH", "primaryKeyTable": "BLOCKS", "primaryKeyColumn": "ID", "foreignKeySchema": "ETH", "foreignKeyTable": "TX", "foreignKeyColumn": "BLOCK_ID"}]`,
+	"/discover/refs/foreignkey?column=PREV_BLOCK_ID&schema=ETH&table=TX": `[{"primaryKeySchema": "ETH", "primaryKeyTable": "BLOCKS", "primaryKeyColumn": "ID", "foreignKeySchema": "ETH", "foreignKeyTable": "TX", "foreignKeyColumn": "PREV_BLOCK_ID"}]`,
+
+	// A reference to ETH.BLOCKS, not a foreign key of it
+	"/discover/refs/foreignkey?column=ID&schema=ETH&table=BLOCKS": `[{"primaryKeySchema": "ETH", "primaryKeyTable": "BLOCKS", "primaryKeyColumn": "ID", "foreignKeySchema": "ETH", "foreignKeyTable": "TX", "foreignKeyColumn": "BLOCK_ID"}]`,
+
+	"/discover/views?": `[
+		{"viewName": "ETH.BIG_TX", "viewText": "SELECT * FROM ETH.RICH_TX WHERE VALUE > 100"},
+		{"viewName": "ETH.RICH_TX", "viewText": "SELECT * FROM ETH.TX WHERE VALUE > 10"},
+		{"viewName": "ETH.RECENT", "viewText": "SELECT * FROM ETH.BLOCKS WHERE ID > :min_id", "parameters": [{"name": "MIN_ID", "type": "BIGINT"}]},
+		{"viewName": "BTC.OTHER", "viewText": "SELECT 1"}
+	]`,
+}
+
+func TestExportSchemaDDL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		response, ok := exportResponses[request.URL.Path+"?"+request.URL.RawQuery]
+		if !ok {
+			response = "[]"
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	t.Setenv("BASEURL_DISCOVERY", server.URL)
+
+	export, err := NewClient("test").ExportSchemaDDL(context.Background(), "eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var script bytes.Buffer
+	export.WriteScript(&script)
+
+	expected := `-- Schema ETH
+
+CREATE TABLE ETH.BLOCKS (ID BIGINT NOT NULL, HASH VARCHAR, PRIMARY KEY (ID));
+
+CREATE TABLE ETH.TX (ID BIGINT NOT NULL, BLOCK_ID BIGINT NOT NULL, VALUE DECIMAL(18,2), PREV_BLOCK_ID BIGINT, PRIMARY KEY (ID), FOREIGN KEY (BLOCK_ID) REFERENCES ETH.BLOCKS (ID), FOREIGN KEY (PREV_BLOCK_ID) REFERENCES ETH.BLOCKS (ID));
+CREATE INDEX TX_BLOCK ON ETH.TX (BLOCK_ID);
+
+CREATE VIEW ETH.RICH_TX AS SELECT * FROM ETH.TX WHERE VALUE > 10;
+
+CREATE VIEW ETH.BIG_TX AS SELECT * FROM ETH.RICH_TX WHERE VALUE > 100;
+
+-- parameterized view ETH.RECENT (MIN_ID BIGINT), create it with Client.CreateView:
+-- SELECT * FROM ETH.BLOCKS WHERE ID > :min_id
+`
+	if script.String() != expected {
+		t.Errorf("unexpected script:\n%s", script.String())
+	}
+
+	dir := t.TempDir()
+	filenames, err := export.WriteFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, filename := range filenames {
+		names = append(names, filepath.Base(filename))
+	}
+	if !reflect.DeepEqual(names, []string{"001_ETH.BLOCKS.sql", "002_ETH.TX.sql", "003_ETH.RICH_TX.sql", "004_ETH.BIG_TX.sql", "005_ETH.RECENT.sql"}) {
+		t.Errorf("unexpected files %v", names)
+	}
+
+	content, _ := os.ReadFile(filenames[0])
+	if string(content) != "CREATE TABLE ETH.BLOCKS (ID BIGINT NOT NULL, HASH VARCHAR, PRIMARY KEY (ID));\n" {
+		t.Errorf("unexpected file content %q", content)
+	}
+}
+
+func TestExportStoredTableNames(t *testing.T) {
+	responses := map[string]string{
+		"/discover/table?schema=ETH&scope=ALL":                            `[{"schema": "ETH", "table": "events"}]`,
+		"/discover/table/column?schema=ETH&table=events":                  `[{"column": "ID", "dataType": "BIGINT"}, {"column": "PARENT", "dataType": "BIGINT", "nullable": true}]`,
+		"/discover/table/primarykey?schema=ETH&table=events":              `[{"column": "ID"}]`,
+		"/discover/table/index?schema=ETH&table=events":                   `[{"name": "EVENTS_PARENT", "column": "PARENT", "ordinal": 1}]`,
+		"/discover/refs/foreignkey?column=PARENT&schema=ETH&table=events": `[{"primaryKeySchema": "ETH", "primaryKeyTable": "events", "primaryKeyColumn": "ID", "foreignKeySchema": "ETH", "foreignKeyTable": "events", "foreignKeyColumn": "PARENT"}]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		response, ok := responses[request.URL.Path+"?"+request.URL.RawQuery]
+		if !ok {
+			response = "[]"
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	t.Setenv("BASEURL_DISCOVERY", server.URL)
+
+	export, err := NewClient("test").ExportSchemaDDL(context.Background(), "ETH")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`CREATE TABLE ETH."events" (ID BIGINT NOT NULL, PARENT BIGINT, PRIMARY KEY (ID), FOREIGN KEY (PARENT) REFERENCES ETH."events" (ID))`,
+		`CREATE INDEX EVENTS_PARENT ON ETH."events" (PARENT)`,
+	}
+	if len(export.Objects) != 1 || export.Objects[0].Name != `ETH."events"` || !reflect.DeepEqual(export.Objects[0].Statements, expected) {
+		t.Errorf("expected the table to keep its stored name, got %+v", export.Objects)
+	}
+
+	filenames, err := export.WriteFiles(t.TempDir())
+	if err != nil || filepath.Base(filenames[0]) != "001_ETH.events.sql" {
+		t.Errorf("unexpected files %v: %v", filenames, err)
+	}
+}
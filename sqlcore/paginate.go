@@ -0,0 +1,317 @@
+package sqlcore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Page size used when PageOptions.PageSize is not set
+const DefaultPageSize = 1000
+
+// Settings for Client.Paginate
+type PageOptions struct {
+	// PageSize is the number of rows per page, DefaultPageSize when zero
+	PageSize int
+
+	// KeyColumns turn on keyset pagination: pages are ordered by these columns, which must be unique and non NULL together.
+	// Without them LIMIT/OFFSET pagination is used, and the query needs an ORDER BY for pages to be stable
+	KeyColumns []string
+
+	// Cursor resumes a previous pagination, from Paginator.Cursor
+	Cursor string
+}
+
+// Walks a DQL result one page at a time
+//
+//	paginator, err := client.Paginate("SELECT * FROM ETH.BLOCKS", nil, sqlcore.PageOptions{KeyColumns: []string{"BLOCK_NUMBER"}})
+//	for paginator.Next(ctx) {
+//		page := paginator.Page()
+//	}
+//	err = paginator.Err()
+type Paginator struct {
+	client    *Client
+	sqlText   string
+	resources []string
+	options   PageOptions
+
+	cursor pageCursor
+	page   *ResultSet
+	done   bool
+	err    error
+}
+
+// Position after the last page read. Offset for offset pagination, key values of the last row for keyset pagination
+type pageCursor struct {
+	Query  string        `json:"q"`
+	Offset int           `json:"o,omitempty"`
+	Keys   []interface{} `json:"k,omitempty"`
+	Done   bool          `json:"d,omitempty"`
+}
+
+// Create a paginator over a DQL query. The query must not have its own LIMIT or OFFSET
+func (c *Client) Paginate(sqlText string, resources []string, options PageOptions) (*Paginator, error) {
+	sqlText = strings.TrimRight(strings.TrimSpace(sqlText), ";")
+
+	statement, err := sqlparser.Analyze(sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	if statement.Kind != sqlparser.KindDQL {
+		return nil, fmt.Errorf("only queries can be paginated, found %s", statement.Operation)
+	}
+
+	if hasTopLevelKeyword(statement.Tokens, "LIMIT", "OFFSET", "FETCH") {
+		return nil, errors.New("paginated queries can't have their own LIMIT or OFFSET")
+	}
+
+	if options.PageSize <= 0 {
+		options.PageSize = DefaultPageSize
+	}
+
+	if resources == nil {
+		resources = statement.Resources()
+	}
+
+	paginator := &Paginator{
+		client:    c,
+		sqlText:   sqlText,
+		resources: resources,
+		options:   options,
+		cursor:    pageCursor{Query: queryFingerprint(sqlText, options.KeyColumns)},
+	}
+
+	if options.Cursor != "" {
+		cursor, err := decodeCursor(options.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor.Query != paginator.cursor.Query {
+			return nil, errors.New("cursor belongs to a different query")
+		}
+
+		if len(options.KeyColumns) > 0 && cursor.Keys != nil && len(cursor.Keys) != len(options.KeyColumns) {
+			return nil, errors.New("cursor doesn't match the key columns")
+		}
+
+		paginator.cursor = cursor
+		paginator.done = cursor.Done
+	}
+
+	return paginator, nil
+}
+
+// Fetch the next page. Returns false when there are no more rows or on error, see Err
+func (p *Paginator) Next(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	sqlText, err := p.pageQuery()
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	data, err := p.client.DQLWithRowCount(ctx, sqlText, p.resources, p.options.PageSize)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	page, err := DecodeResultSet(data)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	if len(page.Rows) < p.options.PageSize {
+		p.done = true
+		p.cursor.Done = true
+	}
+
+	if len(page.Rows) == 0 {
+		p.page = nil
+		return false
+	}
+
+	if err := p.advance(page); err != nil {
+		p.err = err
+		return false
+	}
+
+	p.page = page
+	return true
+}
+
+// Page read by the last call to Next
+func (p *Paginator) Page() *ResultSet {
+	return p.page
+}
+
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// Token resuming after the last page read, passed as PageOptions.Cursor
+func (p *Paginator) Cursor() string {
+	encoded, _ := json.Marshal(p.cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func (p *Paginator) pageQuery() (string, error) {
+	size := p.options.PageSize
+	keys := p.options.KeyColumns
+
+	if len(keys) == 0 {
+		return fmt.Sprintf("%s LIMIT %d OFFSET %d", p.sqlText, size, p.cursor.Offset), nil
+	}
+
+	quoted := make([]string, len(keys))
+	for idx, key := range keys {
+		quoted[idx] = quoteIdentifier(key)
+	}
+
+	where := ""
+	if p.cursor.Keys != nil {
+		// (K1 > v1) OR (K1 = v1 AND K2 > v2) ...
+		var alternatives []string
+		for idx := range keys {
+			var conditions []string
+			for previous := 0; previous < idx; previous++ {
+				literal, err := FormatLiteral(p.cursor.Keys[previous])
+				if err != nil {
+					return "", err
+				}
+				conditions = append(conditions, quoted[previous]+" = "+literal)
+			}
+
+			literal, err := FormatLiteral(p.cursor.Keys[idx])
+			if err != nil {
+				return "", err
+			}
+			conditions = append(conditions, quoted[idx]+" > "+literal)
+			alternatives = append(alternatives, "("+strings.Join(conditions, " AND ")+")")
+		}
+		where = " WHERE " + strings.Join(alternatives, " OR ")
+	}
+
+	return fmt.Sprintf("SELECT * FROM (%s) AS SXT_PAGE%s ORDER BY %s LIMIT %d", p.sqlText, where, strings.Join(quoted, ", "), size), nil
+}
+
+// Move the cursor past a page
+func (p *Paginator) advance(page *ResultSet) error {
+	if len(p.options.KeyColumns) == 0 {
+		p.cursor.Offset += len(page.Rows)
+		return nil
+	}
+
+	last := page.Rows[len(page.Rows)-1]
+	keys := make([]interface{}, len(p.options.KeyColumns))
+	for idx, column := range p.options.KeyColumns {
+		value, err := last.Value(strings.Trim(column, `"`))
+		if err != nil {
+			return err
+		}
+
+		if value == nil {
+			return fmt.Errorf("key column %s is NULL, keyset pagination needs non NULL keys", column)
+		}
+		keys[idx] = value
+	}
+
+	p.cursor.Keys = keys
+	return nil
+}
+
+func decodeCursor(token string) (cursor pageCursor, err error) {
+	encoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+// Ties a cursor to its query and key columns
+func queryFingerprint(sqlText string, keyColumns []string) string {
+	sum := sha256.Sum256([]byte(sqlText + "\x00" + strings.Join(keyColumns, ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Reports if a keyword appears outside parentheses
+func hasTopLevelKeyword(tokens []sqlparser.Token, keywords ...string) bool {
+	depth := 0
+	for _, token := range tokens {
+		switch {
+		case token.Type == sqlparser.Punct && token.Text == "(":
+			depth++
+		case token.Type == sqlparser.Punct && token.Text == ")":
+			depth--
+		case depth == 0:
+			for _, keyword := range keywords {
+				if token.Is(keyword) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// Identifier for a name given by the caller. Plain names are upper cased, as SxT stores unquoted names,
+// names already in double quotes are kept and others, e.g. with spaces or reserved words, are quoted
+func quoteIdentifier(name string) string {
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return name
+	}
+
+	if isPlainIdentifier(name) && !sqlparser.IsReserved(strings.ToUpper(name)) {
+		return strings.ToUpper(name)
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Identifier for a name as stored, e.g. from discovery. Only plain upper case names are left unquoted
+func quoteStoredIdentifier(name string) string {
+	if isPlainIdentifier(name) && name == strings.ToUpper(name) && !sqlparser.IsReserved(name) {
+		return name
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func isPlainIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for idx, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r == '_':
+		case r >= '0' && r <= '9' && idx > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package sqlcore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPaginatorQueries(t *testing.T) {
+	client := NewClient("test")
+
+	paginator, err := client.Paginate("SELECT * FROM ETH.BLOCKS ORDER BY ID;", nil, PageOptions{PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paginator.cursor.Offset = 20
+
+	if query, _ := paginator.pageQuery(); query != "SELECT * FROM ETH.BLOCKS ORDER BY ID LIMIT 10 OFFSET 20" {
+		t.Errorf("unexpected offset query %s", query)
+	}
+
+	paginator, err = client.Paginate("SELECT * FROM ETH.BLOCKS", nil, PageOptions{PageSize: 5, KeyColumns: []string{"NUMBER", "hash"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if query, _ := paginator.pageQuery(); query != `SELECT * FROM (SELECT * FROM ETH.BLOCKS) AS SXT_PAGE ORDER BY NUMBER, HASH LIMIT 5` {
+		t.Errorf("unexpected first keyset query %s", query)
+	}
+
+	paginator.cursor.Keys = []interface{}{json.Number("7"), "o'k"}
+	resumed, err := client.Paginate("SELECT * FROM ETH.BLOCKS", nil, PageOptions{PageSize: 5, KeyColumns: []string{"NUMBER", "hash"}, Cursor: paginator.Cursor()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM (SELECT * FROM ETH.BLOCKS) AS SXT_PAGE WHERE (NUMBER > 7) OR (NUMBER = 7 AND HASH > 'o''k') ORDER BY NUMBER, HASH LIMIT 5`
+	if query, _ := resumed.pageQuery(); query != expected {
+		t.Errorf("unexpected resumed keyset query %s", query)
+	}
+
+	// Plain names are upper cased, others and reserved words quoted
+	for name, expected := range map[string]string{
+		"block_number": "BLOCK_NUMBER",
+		`"Mixed"`:      `"Mixed"`,
+		"two words":    `"two words"`,
+		"order":        `"order"`,
+	} {
+		if quoted := quoteIdentifier(name); quoted != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, quoted)
+		}
+	}
+}
+
+func TestPaginateRejects(t *testing.T) {
+	client := NewClient("test")
+
+	for _, sqlText := range []string{
+		"SELECT * FROM ETH.BLOCKS LIMIT 10",
+		"DELETE FROM ETH.BLOCKS",
+	} {
+		if _, err := client.Paginate(sqlText, nil, PageOptions{}); err == nil {
+			t.Errorf("%s: expected an error", sqlText)
+		}
+	}
+
+	paginator, _ := client.Paginate("SELECT * FROM ETH.BLOCKS", nil, PageOptions{})
+	if _, err := client.Paginate("SELECT * FROM ETH.TRANSACTIONS", nil, PageOptions{Cursor: paginator.Cursor()}); err == nil {
+		t.Error("expected a cursor from another query to be rejected")
+	}
+
+	if _, err := client.Paginate("SELECT ID FROM ETH.BLOCKS WHERE ID IN (SELECT ID FROM ETH.T LIMIT 3)", nil, PageOptions{}); err != nil {
+		t.Errorf("nested LIMIT rejected: %v", err)
+	}
+}
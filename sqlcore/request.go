@@ -2,6 +2,7 @@ package sqlcore
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,15 +11,19 @@ import (
 )
 
 func createRequest(requestType, originApp string, postBody []byte) (request *http.Request, err error) {
+	return createRequestWithContext(context.Background(), requestType, originApp, os.Getenv("accessToken"), postBody)
+}
+
+func createRequestWithContext(ctx context.Context, requestType, originApp, accessToken string, postBody []byte) (request *http.Request, err error) {
 	tokenEndPoint := helpers.GetSqlEndpoint(requestType)
 	responseBody := bytes.NewBuffer(postBody)
 
-	request, err = http.NewRequest("POST", tokenEndPoint, responseBody)
+	request, err = http.NewRequestWithContext(ctx, "POST", tokenEndPoint, responseBody)
 	if err != nil {
 		return nil, err
 	}
 
-	bearerToken := fmt.Sprintf("Bearer %s", os.Getenv("accessToken"))
+	bearerToken := fmt.Sprintf("Bearer %s", accessToken)
 	contentType := "application/json"
 	request.Header.Add("Authorization", bearerToken)
 	request.Header.Add("Content-Type", contentType)
@@ -0,0 +1,534 @@
+package sqlcore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column types inferred from DQL responses
+type ColumnType string
+
+const (
+	// Only NULL values were seen
+	TypeNull      ColumnType = "NULL"
+	TypeInteger   ColumnType = "INTEGER"
+	TypeDecimal   ColumnType = "DECIMAL"
+	TypeBoolean   ColumnType = "BOOLEAN"
+	TypeTimestamp ColumnType = "TIMESTAMP"
+	TypeVarchar   ColumnType = "VARCHAR"
+	TypeJSON      ColumnType = "JSON"
+)
+
+var (
+	ErrNullValue      = errors.New("value is NULL")
+	ErrColumnNotFound = errors.New("column not found")
+)
+
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Decoded DQL result with columns in response order
+type ResultSet struct {
+	Rows []Row
+
+	columns *columnSet
+}
+
+// Columns in the order of the response. Types are inferred from every row
+func (rs *ResultSet) Columns() []Column {
+	return rs.columns.columns
+}
+
+func (rs *ResultSet) ColumnNames() (names []string) {
+	for _, column := range rs.columns.columns {
+		names = append(names, column.Name)
+	}
+
+	return names
+}
+
+// A row of a result set. Values are nil, json.Number, string, bool or json.RawMessage for nested values.
+// Columns are looked up by name, case insensitively as SxT returns upper cased names
+type Row struct {
+	values  []interface{}
+	columns *columnSet
+}
+
+// Run a DQL query and decode the result
+func (c *Client) Query(ctx context.Context, sqlText string, resources []string) (*ResultSet, error) {
+	return c.query(ctx, sqlText, resources, true)
+}
+
+// Query, decrypting encrypted values when decrypt is set
+func (c *Client) query(ctx context.Context, sqlText string, resources []string, decrypt bool) (*ResultSet, error) {
+	stream, err := c.stream(ctx, sqlText, resources, 0, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	resultSet := &ResultSet{columns: stream.reader.columns}
+	for stream.Next() {
+		resultSet.Rows = append(resultSet.Rows, stream.Row())
+	}
+
+	if stream.Err() != nil {
+		return nil, stream.Err()
+	}
+
+	return resultSet, nil
+}
+
+// Decode a DQL response body, e.g. from sqlcore.DQL
+func DecodeResultSet(data []byte) (*ResultSet, error) {
+	reader := newRowReader(bytes.NewReader(data))
+	resultSet := &ResultSet{columns: reader.columns}
+
+	for {
+		row, ok, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return resultSet, nil
+		}
+
+		resultSet.Rows = append(resultSet.Rows, row)
+	}
+}
+
+// Raw value of a column
+func (r Row) Value(column string) (interface{}, error) {
+	idx, ok := r.columns.lookup(column)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrColumnNotFound, column)
+	}
+
+	if idx >= len(r.values) {
+		return nil, nil
+	}
+
+	return r.values[idx], nil
+}
+
+// Values in column order
+func (r Row) Values() []interface{} {
+	values := make([]interface{}, len(r.columns.columns))
+	copy(values, r.values)
+
+	return values
+}
+
+func (r Row) IsNull(column string) bool {
+	value, err := r.Value(column)
+	return err == nil && value == nil
+}
+
+func (r Row) Int64(column string) (int64, error) {
+	text, err := r.number(column)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("column %s: %q is not an int64", column, text)
+	}
+
+	return value, nil
+}
+
+func (r Row) Float64(column string) (float64, error) {
+	text, err := r.number(column)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("column %s: %q is not a float64", column, text)
+	}
+
+	return value, nil
+}
+
+// Exact value of a DECIMAL or integer column
+func (r Row) Decimal(column string) (*big.Rat, error) {
+	text, err := r.number(column)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("column %s: %q is not a decimal", column, text)
+	}
+
+	return value, nil
+}
+
+// Text of any non NULL value. Numbers keep their exact response text
+func (r Row) String(column string) (string, error) {
+	value, err := r.nonNull(column)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+func (r Row) Bool(column string) (bool, error) {
+	value, err := r.nonNull(column)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if parsed, err := strconv.ParseBool(v); err == nil {
+			return parsed, nil
+		}
+	case json.Number:
+		if v == "0" || v == "1" {
+			return v == "1", nil
+		}
+	}
+
+	return false, fmt.Errorf("column %s: %v is not a boolean", column, value)
+}
+
+func (r Row) Time(column string) (time.Time, error) {
+	value, err := r.nonNull(column)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("column %s: %v is not a timestamp", column, value)
+	}
+
+	parsed, ok := parseTimestamp(text)
+	if !ok {
+		return time.Time{}, fmt.Errorf("column %s: %q is not a timestamp", column, text)
+	}
+
+	return parsed, nil
+}
+
+func (r Row) NullInt64(column string) (sql.NullInt64, error) {
+	if r.IsNull(column) {
+		return sql.NullInt64{}, nil
+	}
+
+	value, err := r.Int64(column)
+	return sql.NullInt64{Int64: value, Valid: err == nil}, err
+}
+
+func (r Row) NullFloat64(column string) (sql.NullFloat64, error) {
+	if r.IsNull(column) {
+		return sql.NullFloat64{}, nil
+	}
+
+	value, err := r.Float64(column)
+	return sql.NullFloat64{Float64: value, Valid: err == nil}, err
+}
+
+// NULL decimals are returned as nil
+func (r Row) NullDecimal(column string) (*big.Rat, error) {
+	if r.IsNull(column) {
+		return nil, nil
+	}
+
+	return r.Decimal(column)
+}
+
+func (r Row) NullString(column string) (sql.NullString, error) {
+	if r.IsNull(column) {
+		return sql.NullString{}, nil
+	}
+
+	value, err := r.String(column)
+	return sql.NullString{String: value, Valid: err == nil}, err
+}
+
+func (r Row) NullBool(column string) (sql.NullBool, error) {
+	if r.IsNull(column) {
+		return sql.NullBool{}, nil
+	}
+
+	value, err := r.Bool(column)
+	return sql.NullBool{Bool: value, Valid: err == nil}, err
+}
+
+func (r Row) NullTime(column string) (sql.NullTime, error) {
+	if r.IsNull(column) {
+		return sql.NullTime{}, nil
+	}
+
+	value, err := r.Time(column)
+	return sql.NullTime{Time: value, Valid: err == nil}, err
+}
+
+func (r Row) nonNull(column string) (interface{}, error) {
+	value, err := r.Value(column)
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, fmt.Errorf("column %s: %w", column, ErrNullValue)
+	}
+
+	return value, nil
+}
+
+// Numbers may be sent as JSON numbers or numeric strings
+func (r Row) number(column string) (string, error) {
+	value, err := r.nonNull(column)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return strings.TrimSpace(v), nil
+	}
+
+	return "", fmt.Errorf("column %s: %v is not a number", column, value)
+}
+
+// Columns shared by the rows of a result. Later rows may add columns
+type columnSet struct {
+	columns []Column
+	exact   map[string]int
+	folded  map[string]int
+}
+
+func newColumnSet() *columnSet {
+	return &columnSet{exact: map[string]int{}, folded: map[string]int{}}
+}
+
+func (cs *columnSet) lookup(name string) (int, bool) {
+	if idx, ok := cs.exact[name]; ok {
+		return idx, true
+	}
+
+	idx, ok := cs.folded[strings.ToUpper(name)]
+	return idx, ok
+}
+
+func (cs *columnSet) add(name string) int {
+	if idx, ok := cs.exact[name]; ok {
+		return idx
+	}
+
+	idx := len(cs.columns)
+	cs.columns = append(cs.columns, Column{Name: name, Type: TypeNull})
+	cs.exact[name] = idx
+	if _, ok := cs.folded[strings.ToUpper(name)]; !ok {
+		cs.folded[strings.ToUpper(name)] = idx
+	}
+
+	return idx
+}
+
+// Widen the column type with the type of a new value
+func (cs *columnSet) observe(idx int, value interface{}) {
+	observed := valueType(value)
+	current := cs.columns[idx].Type
+
+	switch {
+	case observed == TypeNull || observed == current:
+	case current == TypeNull:
+		cs.columns[idx].Type = observed
+	case (current == TypeInteger && observed == TypeDecimal) || (current == TypeDecimal && observed == TypeInteger):
+		cs.columns[idx].Type = TypeDecimal
+	case current == TypeTimestamp && observed == TypeVarchar, current == TypeVarchar && observed == TypeTimestamp:
+		cs.columns[idx].Type = TypeVarchar
+	default:
+		cs.columns[idx].Type = TypeJSON
+	}
+}
+
+func valueType(value interface{}) ColumnType {
+	switch v := value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return TypeDecimal
+		}
+		return TypeInteger
+	case string:
+		if _, ok := parseTimestamp(v); ok {
+			return TypeTimestamp
+		}
+		return TypeVarchar
+	}
+
+	return TypeJSON
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func parseTimestamp(text string) (time.Time, bool) {
+	// Cheap check before trying every layout
+	if len(text) < 10 || text[4] != '-' || text[7] != '-' {
+		return time.Time{}, false
+	}
+
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Reads the rows of a DQL response, a JSON array of objects, one row at a time
+type rowReader struct {
+	decoder *json.Decoder
+	columns *columnSet
+	started bool
+	done    bool
+
+	// decrypt is set by Client.Encryption, values are decrypted before their type is observed
+	decrypt func(column string, value interface{}) (interface{}, error)
+}
+
+func newRowReader(r io.Reader) *rowReader {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	return &rowReader{decoder: decoder, columns: newColumnSet()}
+}
+
+func (rr *rowReader) next() (row Row, ok bool, err error) {
+	if rr.done {
+		return Row{}, false, nil
+	}
+
+	if !rr.started {
+		rr.started = true
+		if err := rr.expectDelim('['); err != nil {
+			return Row{}, false, err
+		}
+	}
+
+	if !rr.decoder.More() {
+		rr.done = true
+		if err := rr.expectDelim(']'); err != nil {
+			return Row{}, false, err
+		}
+		return Row{}, false, nil
+	}
+
+	if err := rr.expectDelim('{'); err != nil {
+		return Row{}, false, err
+	}
+
+	row = Row{columns: rr.columns, values: make([]interface{}, len(rr.columns.columns))}
+	for rr.decoder.More() {
+		keyToken, err := rr.decoder.Token()
+		if err != nil {
+			return Row{}, false, err
+		}
+		key, _ := keyToken.(string)
+
+		var raw json.RawMessage
+		if err := rr.decoder.Decode(&raw); err != nil {
+			return Row{}, false, err
+		}
+
+		value, err := decodeValue(raw)
+		if err != nil {
+			return Row{}, false, err
+		}
+
+		if rr.decrypt != nil {
+			if value, err = rr.decrypt(key, value); err != nil {
+				return Row{}, false, fmt.Errorf("column %s: %w", key, err)
+			}
+		}
+
+		idx := rr.columns.add(key)
+		for len(row.values) <= idx {
+			row.values = append(row.values, nil)
+		}
+		row.values[idx] = value
+		rr.columns.observe(idx, value)
+	}
+
+	if err := rr.expectDelim('}'); err != nil {
+		return Row{}, false, err
+	}
+
+	return row, true, nil
+}
+
+func (rr *rowReader) expectDelim(delim json.Delim) error {
+	token, err := rr.decoder.Token()
+	if err != nil {
+		return fmt.Errorf("unexpected DQL response: %w", err)
+	}
+
+	if token != delim {
+		return fmt.Errorf("unexpected DQL response: expected %s, found %v", delim, token)
+	}
+
+	return nil
+}
+
+// Scalars are decoded, nested arrays and objects are kept raw
+func decodeValue(raw json.RawMessage) (interface{}, error) {
+	switch raw[0] {
+	case 'n':
+		return nil, nil
+	case 't', 'f':
+		return raw[0] == 't', nil
+	case '"':
+		var text string
+		err := json.Unmarshal(raw, &text)
+		return text, err
+	case '{', '[':
+		return raw, nil
+	}
+
+	return json.Number(raw), nil
+}
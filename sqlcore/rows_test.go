@@ -0,0 +1,61 @@
+package sqlcore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeResultSet(t *testing.T) {
+	data := []byte(`[
+		{"ID": 9007199254740993, "TEST": "x5", "PRICE": 10.25, "ACTIVE": true, "CREATED": "2023-03-01T10:00:00Z", "NOTE": null},
+		{"ID": 6, "TEST": "x6", "PRICE": 3, "ACTIVE": false, "CREATED": "2023-03-02 11:30:00", "NOTE": "n"}
+	]`)
+
+	resultSet, err := DecodeResultSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(resultSet.ColumnNames(), []string{"ID", "TEST", "PRICE", "ACTIVE", "CREATED", "NOTE"}) {
+		t.Errorf("unexpected column order %v", resultSet.ColumnNames())
+	}
+
+	types := []ColumnType{TypeInteger, TypeVarchar, TypeDecimal, TypeBoolean, TypeTimestamp, TypeVarchar}
+	for idx, column := range resultSet.Columns() {
+		if column.Type != types[idx] {
+			t.Errorf("column %s: expected %s, got %s", column.Name, types[idx], column.Type)
+		}
+	}
+
+	row := resultSet.Rows[0]
+	if id, _ := row.Int64("id"); id != 9007199254740993 {
+		t.Errorf("large integer lost precision: %d", id)
+	}
+
+	if price, _ := row.Decimal("PRICE"); price.FloatString(2) != "10.25" {
+		t.Errorf("unexpected decimal %v", price)
+	}
+
+	if created, _ := resultSet.Rows[1].Time("CREATED"); created.Hour() != 11 {
+		t.Errorf("unexpected timestamp %v", created)
+	}
+
+	if _, err := row.String("NOTE"); !errors.Is(err, ErrNullValue) {
+		t.Errorf("expected ErrNullValue, got %v", err)
+	}
+
+	if note, err := row.NullString("NOTE"); err != nil || note.Valid {
+		t.Errorf("expected an invalid NullString, got %v %v", note, err)
+	}
+
+	if _, err := row.Value("MISSING"); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+}
+
+func TestDecodeResultSetRejectsErrors(t *testing.T) {
+	if _, err := DecodeResultSet([]byte(`{"title": "Bad request"}`)); err == nil {
+		t.Error("expected an error for a non array response")
+	}
+}
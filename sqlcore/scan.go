@@ -0,0 +1,358 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	nullTimeType        = reflect.TypeOf(sql.NullTime{})
+	ratType             = reflect.TypeOf(big.Rat{})
+	numberType          = reflect.TypeOf(json.Number(""))
+)
+
+// Run a DQL query and scan every row into a T.
+// T is a struct, or a pointer to one, whose fields map to columns by their `sxt:"column"` tag or by name, case insensitively.
+// Embedded structs are flattened. NULLs need pointer or sql.Null* fields, or are left zero unless Client.StrictScan is set.
+// resources are optional and found by analyzing the statement when omitted
+func QueryInto[T any](ctx context.Context, client *Client, sqlText string, resources ...string) ([]T, error) {
+	resultSet, err := client.Query(ctx, sqlText, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return ScanInto[T](resultSet, client.StrictScan)
+}
+
+// Run a DQL query returning exactly one row and scan it into a T.
+// Returns sql.ErrNoRows when the query returns no rows
+func QueryOne[T any](ctx context.Context, client *Client, sqlText string, resources ...string) (T, error) {
+	var zero T
+
+	values, err := QueryInto[T](ctx, client, sqlText, resources...)
+	if err != nil {
+		return zero, err
+	}
+
+	switch len(values) {
+	case 0:
+		return zero, sql.ErrNoRows
+	case 1:
+		return values[0], nil
+	}
+
+	return zero, fmt.Errorf("expected one row, query returned %d", len(values))
+}
+
+// Scan a result set into structs. In strict mode every column needs a field, every field a column
+// and NULLs are only accepted by pointer and sql.Scanner fields
+func ScanInto[T any](resultSet *ResultSet, strict bool) (values []T, err error) {
+	targetType := reflect.TypeOf((*T)(nil)).Elem()
+	structType := targetType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot scan into %s, a struct is expected", targetType)
+	}
+
+	fields := structFields(structType)
+
+	// Column -> field index path
+	paths := make([][]int, len(resultSet.Columns()))
+	used := map[string]bool{}
+	for idx, column := range resultSet.Columns() {
+		name := strings.ToUpper(column.Name)
+		field, ok := fields[name]
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("column %s has no field in %s", column.Name, structType)
+			}
+			continue
+		}
+
+		paths[idx] = field.index
+		used[name] = true
+	}
+
+	// Columns are inferred from the rows, so an empty result has none to check
+	if strict && len(resultSet.Rows) > 0 {
+		for name, field := range fields {
+			if !used[name] {
+				return nil, fmt.Errorf("field %s of %s has no column %s", field.name, structType, name)
+			}
+		}
+	}
+
+	values = make([]T, 0, len(resultSet.Rows))
+	for rowIdx, row := range resultSet.Rows {
+		target := reflect.New(structType).Elem()
+
+		for idx, column := range resultSet.Columns() {
+			if paths[idx] == nil {
+				continue
+			}
+
+			field := fieldByIndex(target, paths[idx])
+			if err := scanColumn(row, column.Name, field, strict); err != nil {
+				return nil, fmt.Errorf("row %d: %w", rowIdx, err)
+			}
+		}
+
+		if targetType.Kind() == reflect.Ptr {
+			values = append(values, target.Addr().Interface().(T))
+		} else {
+			values = append(values, target.Interface().(T))
+		}
+	}
+
+	return values, nil
+}
+
+type scanField struct {
+	name  string
+	index []int
+}
+
+var structFieldsCache sync.Map
+
+// Fields of a struct by upper cased column name, embedded structs flattened.
+// Outer fields win over embedded ones, as in Go field promotion
+func structFields(structType reflect.Type) map[string]scanField {
+	if cached, ok := structFieldsCache.Load(structType); ok {
+		return cached.(map[string]scanField)
+	}
+
+	fields := map[string]scanField{}
+	depths := map[string]int{}
+
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+			tag := field.Tag.Get("sxt")
+			if tag == "-" {
+				continue
+			}
+
+			path := append(append([]int{}, index...), idx)
+
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if field.Anonymous && tag == "" && embedded.Kind() == reflect.Struct && !isScalarStruct(embedded) {
+				// Unexported embedded pointers can't be allocated
+				if field.PkgPath == "" || field.Type.Kind() != reflect.Ptr {
+					walk(embedded, path)
+				}
+				continue
+			}
+
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := strings.ToUpper(strings.Split(tag, ",")[0])
+			if name == "" {
+				name = strings.ToUpper(field.Name)
+			}
+
+			if depth, ok := depths[name]; ok && depth <= len(path) {
+				continue
+			}
+			depths[name] = len(path)
+			fields[name] = scanField{name: field.Name, index: path}
+		}
+	}
+	walk(structType, nil)
+
+	structFieldsCache.Store(structType, fields)
+	return fields
+}
+
+// Structs scanned as one value rather than flattened
+func isScalarStruct(t reflect.Type) bool {
+	return t == timeType || t == ratType || reflect.PtrTo(t).Implements(scannerType) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// Field by index path, allocating nil embedded pointers on the way
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for depth, idx := range index {
+		if depth > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(idx)
+	}
+
+	return value
+}
+
+func scanColumn(row Row, column string, field reflect.Value, strict bool) error {
+	if row.IsNull(column) {
+		switch {
+		case field.Kind() == reflect.Ptr:
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		case field.Addr().Type().Implements(scannerType):
+			return field.Addr().Interface().(sql.Scanner).Scan(nil)
+		case strict:
+			return fmt.Errorf("column %s is NULL, use a pointer or sql.Null* field", column)
+		}
+
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Kind() == reflect.Ptr {
+		target := reflect.New(field.Type().Elem())
+		if err := scanValue(row, column, target.Elem()); err != nil {
+			return err
+		}
+		field.Set(target)
+		return nil
+	}
+
+	return scanValue(row, column, field)
+}
+
+// Scan a non NULL value into a non pointer field
+func scanValue(row Row, column string, field reflect.Value) error {
+	fieldType := field.Type()
+
+	switch {
+	case fieldType == timeType:
+		value, err := row.Time(column)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(value))
+		return nil
+
+	case fieldType == ratType:
+		value, err := row.Decimal(column)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(value).Elem())
+		return nil
+
+	case fieldType == numberType:
+		value, err := row.String(column)
+		if err != nil {
+			return err
+		}
+		field.SetString(value)
+		return nil
+
+	case field.Addr().Type().Implements(scannerType):
+		value, err := scannerSource(row, column, fieldType)
+		if err != nil {
+			return err
+		}
+		return field.Addr().Interface().(sql.Scanner).Scan(value)
+
+	case field.Addr().Type().Implements(textUnmarshalerType):
+		value, err := row.String(column)
+		if err != nil {
+			return err
+		}
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		value, err := row.String(column)
+		if err != nil {
+			return err
+		}
+		field.SetString(value)
+
+	case reflect.Bool:
+		value, err := row.Bool(column)
+		if err != nil {
+			return err
+		}
+		field.SetBool(value)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := row.Int64(column)
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(value) {
+			return fmt.Errorf("column %s: %d overflows %s", column, value, fieldType)
+		}
+		field.SetInt(value)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		text, err := row.String(column)
+		if err != nil {
+			return err
+		}
+		value, err := strconv.ParseUint(text, 10, 64)
+		if err != nil || field.OverflowUint(value) {
+			return fmt.Errorf("column %s: %q doesn't fit %s", column, text, fieldType)
+		}
+		field.SetUint(value)
+
+	case reflect.Float32, reflect.Float64:
+		value, err := row.Float64(column)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(value)
+
+	default:
+		// Nested JSON values into maps, slices and structs
+		text, err := row.String(column)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(text), field.Addr().Interface()); err != nil {
+			return fmt.Errorf("column %s: cannot scan into %s: %w", column, fieldType, err)
+		}
+	}
+
+	return nil
+}
+
+// Value handed to sql.Scanner fields, in the types database/sql drivers use
+func scannerSource(row Row, column string, fieldType reflect.Type) (interface{}, error) {
+	if fieldType == nullTimeType {
+		return row.Time(column)
+	}
+
+	value, err := row.Value(column)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer, nil
+		}
+		return v.String(), nil
+	case json.RawMessage:
+		return []byte(v), nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,92 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type upperText string
+
+func (u *upperText) UnmarshalText(text []byte) error {
+	*u = upperText(strings.ToUpper(string(text)))
+	return nil
+}
+
+type auditColumns struct {
+	Created time.Time `sxt:"CREATED_AT"`
+}
+
+type testRow struct {
+	auditColumns
+	ID    int64
+	Test  *string        `sxt:"test"`
+	Note  sql.NullString `sxt:"NOTE"`
+	Label upperText      `sxt:"LABEL"`
+	Skip  string         `sxt:"-"`
+}
+
+func TestScanInto(t *testing.T) {
+	resultSet, err := DecodeResultSet([]byte(`[
+		{"ID": 5, "TEST": "x5", "NOTE": null, "LABEL": "a", "CREATED_AT": "2023-03-01T10:00:00Z"},
+		{"ID": 6, "TEST": null, "NOTE": "n", "LABEL": "b", "CREATED_AT": "2023-03-02T10:00:00Z"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ScanInto[testRow](resultSet, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rows[0].ID != 5 || *rows[0].Test != "x5" || rows[0].Note.Valid || rows[0].Label != "A" || rows[0].Created.Day() != 1 {
+		t.Errorf("unexpected first row %+v", rows[0])
+	}
+
+	if rows[1].Test != nil || rows[1].Note.String != "n" {
+		t.Errorf("unexpected second row %+v", rows[1])
+	}
+
+	pointers, err := ScanInto[*testRow](resultSet, false)
+	if err != nil || len(pointers) != 2 || pointers[1].ID != 6 {
+		t.Errorf("unexpected pointer rows %v %v", pointers, err)
+	}
+}
+
+func TestScanIntoStrictNull(t *testing.T) {
+	type strictRow struct {
+		ID int64
+	}
+
+	resultSet, _ := DecodeResultSet([]byte(`[{"ID": null}]`))
+	if _, err := ScanInto[strictRow](resultSet, true); err == nil {
+		t.Error("expected an error scanning NULL into int64 in strict mode")
+	}
+
+	resultSet, _ = DecodeResultSet([]byte(`[{"ID": 1, "EXTRA": 2}]`))
+	if _, err := ScanInto[strictRow](resultSet, true); err == nil {
+		t.Error("expected an error for an unmapped column in strict mode")
+	}
+}
+
+func TestQueryOneStrictEmpty(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: &recordingTransport{}}
+	client.StrictScan = true
+
+	rows, err := QueryInto[testRow](context.Background(), client, "SELECT * FROM ETH.T", "ETH.T")
+	if err != nil || len(rows) != 0 {
+		t.Errorf("expected no rows, got %v: %v", rows, err)
+	}
+
+	if _, err := QueryOne[testRow](context.Background(), client, "SELECT * FROM ETH.T", "ETH.T"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
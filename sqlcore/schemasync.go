@@ -0,0 +1,311 @@
+package sqlcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+)
+
+// Returned by ApplySync for plans with destructive changes when they are not allowed
+var ErrDestructiveChange = errors.New("plan drops or rewrites columns")
+
+// A column of a live table, from discovery.ListColumns
+type liveColumn struct {
+	Column    string
+	DataType  string
+	Precision int
+	Scale     int
+	Nullable  bool
+}
+
+// Columns and primary key of a live table. Replaced in tests
+var liveTable = discoverTable
+
+// A statement of a sync plan
+type SchemaChange struct {
+	SQL         string
+	Description string
+
+	// Destructive changes drop a column, losing its data
+	Destructive bool
+}
+
+// ALTER TABLE statements bringing a live table to its definition
+type SyncPlan struct {
+	Table   string
+	Changes []SchemaChange
+
+	// Unsupported differences can't be fixed with ALTER TABLE, e.g. primary key changes.
+	// ApplySync refuses plans with unsupported differences
+	Unsupported []string
+}
+
+// Reports if the plan drops columns
+func (p *SyncPlan) Destructive() bool {
+	for _, change := range p.Changes {
+		if change.Destructive {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reports if the live table already matches its definition
+func (p *SyncPlan) Empty() bool {
+	return len(p.Changes) == 0 && len(p.Unsupported) == 0
+}
+
+// Settings for Client.ApplySync
+type SyncOptions struct {
+	// AllowDestructive runs changes dropping columns. Without it, plans with such changes are refused
+	AllowDestructive bool
+}
+
+// Compare a definition with the live table found by the discovery APIs and plan the ALTER TABLE statements
+// to sync them. Columns only in the definition are added, columns only in the table are dropped and nullable columns
+// whose type or nullability changed are dropped and added again. Such changes to NOT NULL columns are unsupported.
+// Nothing is run, see ApplySync
+func (c *Client) PlanSync(ctx context.Context, def *TableDef) (*SyncPlan, error) {
+	columns, primaryKey, err := c.liveTable(def.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s doesn't exist, create it with CreateTableDef", def.Name)
+	}
+
+	return planSync(def, columns, primaryKey), nil
+}
+
+func planSync(def *TableDef, columns []liveColumn, primaryKey []string) *SyncPlan {
+	plan := &SyncPlan{Table: def.Name}
+
+	live := map[string]liveColumn{}
+	for _, column := range columns {
+		live[strings.ToUpper(column.Column)] = column
+	}
+
+	wanted := map[string]bool{}
+	for _, column := range def.Columns {
+		wanted[strings.ToUpper(column.Name)] = true
+	}
+
+	for _, column := range columns {
+		if !wanted[strings.ToUpper(column.Column)] {
+			plan.Changes = append(plan.Changes, SchemaChange{
+				SQL:         fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", def.Name, quoteStoredIdentifier(column.Column)),
+				Description: "drop column " + column.Column,
+				Destructive: true,
+			})
+		}
+	}
+
+	for _, column := range def.Columns {
+		current, ok := live[strings.ToUpper(column.Name)]
+		if !ok {
+			plan.Changes = append(plan.Changes, SchemaChange{
+				SQL:         fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", def.Name, column.definition()),
+				Description: "add column " + column.Name,
+			})
+			continue
+		}
+
+		var differences []string
+		if currentType := current.sqlType(); !sameType(column.Type, currentType) {
+			differences = append(differences, fmt.Sprintf("type %s to %s", currentType, column.Type))
+		}
+		if current.Nullable != column.Nullable && !isKey(primaryKey, column.Name) {
+			differences = append(differences, fmt.Sprintf("nullable %t to %t", current.Nullable, column.Nullable))
+		}
+
+		if len(differences) == 0 {
+			continue
+		}
+
+		if isKey(primaryKey, column.Name) {
+			plan.Unsupported = append(plan.Unsupported, fmt.Sprintf("primary key column %s changes %s", column.Name, strings.Join(differences, ", ")))
+			continue
+		}
+
+		// Adding a NOT NULL column fails on a populated table, once the DROP already lost the data
+		if !column.Nullable {
+			plan.Unsupported = append(plan.Unsupported, fmt.Sprintf("NOT NULL column %s changes %s, it can't be dropped and added again", column.Name, strings.Join(differences, ", ")))
+			continue
+		}
+
+		description := fmt.Sprintf("rewrite column %s: %s", column.Name, strings.Join(differences, ", "))
+		plan.Changes = append(plan.Changes,
+			SchemaChange{
+				SQL:         fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", def.Name, quoteIdentifier(column.Name)),
+				Description: description,
+				Destructive: true,
+			},
+			SchemaChange{
+				SQL:         fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", def.Name, column.definition()),
+				Description: description,
+			},
+		)
+	}
+
+	if !sameColumns(def.PrimaryKey, primaryKey) {
+		plan.Unsupported = append(plan.Unsupported, fmt.Sprintf("primary key changes from (%s) to (%s), the table must be recreated",
+			strings.Join(primaryKey, ", "), strings.Join(def.PrimaryKey, ", ")))
+	}
+
+	return plan
+}
+
+// Run the statements of a plan in order. Plans with unsupported differences are refused, and so are
+// destructive plans unless AllowDestructive is set. Statements are not transactional: on failure,
+// the statements before it stay applied and the returned error names the failed change
+func (c *Client) ApplySync(ctx context.Context, plan *SyncPlan, options SyncOptions) error {
+	if len(plan.Unsupported) > 0 {
+		return fmt.Errorf("%s can't be synced: %s", plan.Table, strings.Join(plan.Unsupported, "; "))
+	}
+
+	if plan.Destructive() && !options.AllowDestructive {
+		return fmt.Errorf("%s: %w, set AllowDestructive to apply it", plan.Table, ErrDestructiveChange)
+	}
+
+	for idx, change := range plan.Changes {
+		if err := c.DDL(ctx, change.SQL, nil); err != nil {
+			return fmt.Errorf("change %d (%s): %w", idx+1, change.Description, err)
+		}
+	}
+
+	return nil
+}
+
+// Type as written in DDL, with precision and scale for decimals
+func (c liveColumn) sqlType() string {
+	dataType := strings.ToUpper(strings.TrimSpace(c.DataType))
+	if (dataType == "DECIMAL" || dataType == "NUMERIC") && c.Precision > 0 {
+		return fmt.Sprintf("DECIMAL(%d,%d)", c.Precision, c.Scale)
+	}
+
+	return dataType
+}
+
+var typeAliases = map[string]string{
+	"INTEGER":           "INT",
+	"INT4":              "INT",
+	"INT8":              "BIGINT",
+	"INT2":              "SMALLINT",
+	"BOOL":              "BOOLEAN",
+	"FLOAT8":            "DOUBLE",
+	"DOUBLE PRECISION":  "DOUBLE",
+	"FLOAT4":            "REAL",
+	"NUMERIC":           "DECIMAL",
+	"DEC":               "DECIMAL",
+	"CHARACTER VARYING": "VARCHAR",
+	"BINARY":            "VARBINARY",
+}
+
+// Compare types ignoring aliases and spaces. Parameters are only compared when the definition has them
+func sameType(defined, live string) bool {
+	normalize := func(text string) (name, params string) {
+		text = strings.ToUpper(strings.TrimSpace(text))
+		name, params, _ = strings.Cut(text, "(")
+		name = strings.TrimSpace(name)
+		if alias, ok := typeAliases[name]; ok {
+			name = alias
+		}
+		return name, strings.ReplaceAll(params, " ", "")
+	}
+
+	definedName, definedParams := normalize(defined)
+	liveName, liveParams := normalize(live)
+
+	return definedName == liveName && (definedParams == "" || definedParams == liveParams)
+}
+
+func isKey(keys []string, column string) bool {
+	for _, key := range keys {
+		if strings.EqualFold(key, column) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for idx := range a {
+		if !strings.EqualFold(a[idx], b[idx]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Columns and primary key of a table in the environment of the client
+func (c *Client) liveTable(table string) (columns []liveColumn, primaryKey []string, err error) {
+	if c.DescribeTable == nil {
+		return liveTable(table)
+	}
+
+	discovered, primaryKey, err := c.DescribeTable(table)
+	if err != nil || len(discovered) == 0 {
+		return nil, nil, err
+	}
+
+	return liveColumns(discovered), primaryKey, nil
+}
+
+// Columns and primary key of a SCHEMA.TABLE from the discovery APIs
+func discoverTable(table string) (columns []liveColumn, primaryKey []string, err error) {
+	parts := strings.Split(strings.ToUpper(table), ".")
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("%s is not a SCHEMA.TABLE name", table)
+	}
+
+	return discoverStoredTable(parts[0], parts[1])
+}
+
+// Columns and primary key of a table by its schema and name as stored, which may be lower case
+func discoverStoredTable(schema, table string) (columns []liveColumn, primaryKey []string, err error) {
+	discovered, errMsg, status := discovery.ListColumns(schema, table)
+	if !status {
+		return nil, nil, fmt.Errorf("unable to discover the columns of %s.%s: %s", schema, table, errMsg)
+	}
+
+	columns = liveColumns(discovered)
+	if len(columns) == 0 {
+		return nil, nil, nil
+	}
+
+	keys, errMsg, status := discovery.ListTablePrimaryKey(schema, table)
+	if !status {
+		return nil, nil, fmt.Errorf("unable to discover the primary key of %s.%s: %s", schema, table, errMsg)
+	}
+
+	for _, key := range keys {
+		primaryKey = append(primaryKey, key.Column)
+	}
+
+	return columns, primaryKey, nil
+}
+
+func liveColumns(discovered []discovery.Column) (columns []liveColumn) {
+	for _, column := range discovered {
+		columns = append(columns, liveColumn{
+			Column:    column.Column,
+			DataType:  column.DataType,
+			Precision: column.Precision,
+			Scale:     column.Scale,
+			Nullable:  column.Nullable,
+		})
+	}
+
+	return columns
+}
@@ -0,0 +1,151 @@
+package sqlcore
+
+import (
+	"context"
+	"io"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Rows of a DQL response decoded one at a time, without reading the whole response in memory
+//
+//	stream, err := client.Stream(ctx, "SELECT * FROM ETH.BLOCKS", nil)
+//	defer stream.Close()
+//	for stream.Next() {
+//		row := stream.Row()
+//	}
+//	err = stream.Err()
+type RowStream struct {
+	body   io.ReadCloser
+	reader *rowReader
+	cancel context.CancelFunc
+
+	row    Row
+	err    error
+	closed bool
+}
+
+// Run a DQL query and stream its rows. The stream must be closed, closing it early drops the connection
+func (c *Client) Stream(ctx context.Context, sqlText string, resources []string) (*RowStream, error) {
+	return c.StreamWithRowCount(ctx, sqlText, resources, 0)
+}
+
+// Stream at most rowCount rows. If rowCount is 0, then streams all data without limit
+func (c *Client) StreamWithRowCount(ctx context.Context, sqlText string, resources []string, rowCount int) (*RowStream, error) {
+	return c.stream(ctx, sqlText, resources, rowCount, true)
+}
+
+func (c *Client) stream(ctx context.Context, sqlText string, resources []string, rowCount int, decrypt bool) (*RowStream, error) {
+	biscuitArray, resources, err := c.prepare(sqlText, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	response, err := c.send(ctx, "dql", queryExecutionBody(sqlText, biscuitArray, resources, rowCount))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if response.StatusCode != 200 {
+		defer cancel()
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &GatewayError{StatusCode: response.StatusCode, Body: string(body)}
+	}
+
+	stream := NewRowStream(response.Body)
+	stream.cancel = cancel
+	if decrypt && c.Encryption != nil {
+		var tables []string
+		if statement, err := sqlparser.Analyze(sqlText); err == nil {
+			tables = statement.Resources()
+		}
+		stream.reader.decrypt = c.Encryption.decrypter(ctx, tables)
+	}
+	return stream, nil
+}
+
+// Stream the rows of a DQL response body. The body is closed with the stream
+func NewRowStream(body io.ReadCloser) *RowStream {
+	return &RowStream{body: body, reader: newRowReader(body)}
+}
+
+// Decode the next row. Returns false at the end of the rows or on error, see Err
+func (s *RowStream) Next() bool {
+	if s.closed || s.err != nil {
+		return false
+	}
+
+	row, ok, err := s.reader.next()
+	if err != nil {
+		s.err = err
+		s.Close()
+		return false
+	}
+
+	if !ok {
+		s.Close()
+		return false
+	}
+
+	s.row = row
+	return true
+}
+
+// Row decoded by the last call to Next
+func (s *RowStream) Row() Row {
+	return s.row
+}
+
+// Columns seen so far, in the order of the response. Rows read later may add columns or widen types
+func (s *RowStream) Columns() []Column {
+	return s.reader.columns.columns
+}
+
+func (s *RowStream) Err() error {
+	return s.err
+}
+
+// Stop reading. Rows left unread are not downloaded
+func (s *RowStream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.body.Close()
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	return err
+}
+
+// Send the rows of the stream on a channel until the rows end, ctx is done or an error occurs.
+// The channel is closed at the end and the stream with it; Err reports why it ended
+func (s *RowStream) Chan(ctx context.Context) <-chan Row {
+	rows := make(chan Row)
+
+	go func() {
+		defer close(rows)
+		defer s.Close()
+
+		for s.Next() {
+			select {
+			case rows <- s.Row():
+			case <-ctx.Done():
+				s.err = ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return rows
+}
@@ -0,0 +1,62 @@
+package sqlcore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestRowStream(t *testing.T) {
+	body := &trackedBody{Reader: strings.NewReader(`[{"ID": 1, "NAME": "a"}, {"ID": 2, "NAME": null}, {"ID": 3, "NAME": "c"}]`)}
+	stream := NewRowStream(body)
+
+	var ids []int64
+	for stream.Next() {
+		id, err := stream.Row().Int64("ID")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+
+		if id == 2 {
+			stream.Close()
+		}
+	}
+
+	if stream.Err() != nil {
+		t.Fatal(stream.Err())
+	}
+
+	if len(ids) != 2 || !body.closed {
+		t.Errorf("expected the stream to stop after closing, read %v, closed %v", ids, body.closed)
+	}
+
+	if names := stream.Columns(); len(names) != 2 || names[1].Type != TypeVarchar {
+		t.Errorf("unexpected columns %v", names)
+	}
+}
+
+func TestRowStreamChan(t *testing.T) {
+	body := &trackedBody{Reader: strings.NewReader(`[{"ID": 1}, {"ID": 2}, {"ID": 3}`)}
+	stream := NewRowStream(body)
+
+	count := 0
+	for range stream.Chan(context.Background()) {
+		count++
+	}
+
+	if count != 3 || stream.Err() == nil || !body.closed {
+		t.Errorf("expected 3 rows and a truncation error, got %d rows, error %v", count, stream.Err())
+	}
+}
@@ -0,0 +1,237 @@
+package sqlcore
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Who may read and write a table. Read more here https://docs.spaceandtime.io/docs/secure-your-table
+type AccessType string
+
+const (
+	// Anyone can read, writes need biscuits
+	AccessPublicRead AccessType = "public_read"
+
+	// Anyone can read and insert, updates and deletes need biscuits
+	AccessPublicAppend AccessType = "public_append"
+
+	// Anyone can read and write
+	AccessPublicWrite AccessType = "public_write"
+
+	// Every operation needs biscuits
+	AccessPermissioned AccessType = "permissioned"
+
+	// Permissioned, with encrypted columns
+	AccessEncrypted AccessType = "encrypted"
+)
+
+// Reports if the gateway supports the access type
+func (a AccessType) Valid() bool {
+	switch a {
+	case AccessPublicRead, AccessPublicAppend, AccessPublicWrite, AccessPermissioned, AccessEncrypted:
+		return true
+	}
+
+	return false
+}
+
+// Gateway options of a new table, rendered in its WITH clause
+type TableOptions struct {
+	AccessType AccessType
+
+	// PublicKey owns the table and verifies its biscuits. Derived from OwnerKey when empty
+	PublicKey ed25519.PublicKey
+
+	// OwnerKey is optional. When set, Client.CreateTable mints the owner biscuit with it
+	OwnerKey ed25519.PrivateKey
+
+	// Immutable tables only accept inserts
+	Immutable bool
+
+	// Tamperproof tables can be queried with proofs
+	Tamperproof bool
+
+	// Extra gateway options, for options without a field
+	Extra map[string]string
+}
+
+// Check the options before anything is sent
+func (o TableOptions) Validate() error {
+	if !o.AccessType.Valid() {
+		return fmt.Errorf("invalid access type %q, expected public_read, public_append, public_write, permissioned or encrypted", o.AccessType)
+	}
+
+	publicKey := o.publicKey()
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("table public key has %d bytes, expected %d", len(publicKey), ed25519.PublicKeySize)
+	}
+
+	if o.OwnerKey != nil {
+		if len(o.OwnerKey) != ed25519.PrivateKeySize {
+			return fmt.Errorf("owner key has %d bytes, expected %d", len(o.OwnerKey), ed25519.PrivateKeySize)
+		}
+		if !bytes.Equal(o.OwnerKey.Public().(ed25519.PublicKey), publicKey) {
+			return errors.New("owner key doesn't match the table public key")
+		}
+	}
+
+	for name, value := range o.Extra {
+		switch strings.ToLower(name) {
+		case "public_key", "access_type", "immutable", "tamperproof":
+			return fmt.Errorf("table option %s has its own field", name)
+		}
+
+		if !isOptionText(name) || !isOptionText(value) {
+			return fmt.Errorf("invalid table option %s=%s", name, value)
+		}
+	}
+
+	return nil
+}
+
+func (o TableOptions) publicKey() ed25519.PublicKey {
+	if o.PublicKey == nil && len(o.OwnerKey) == ed25519.PrivateKeySize {
+		return o.OwnerKey.Public().(ed25519.PublicKey)
+	}
+
+	return o.PublicKey
+}
+
+// WITH "public_key=<hex>,access_type=<type>,..."
+func (o TableOptions) withClause() string {
+	options := []string{
+		fmt.Sprintf("public_key=%x", o.publicKey()),
+		"access_type=" + string(o.AccessType),
+	}
+
+	if o.Immutable {
+		options = append(options, "immutable=true")
+	}
+
+	if o.Tamperproof {
+		options = append(options, "tamperproof=true")
+	}
+
+	names := make([]string, 0, len(o.Extra))
+	for name := range o.Extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		options = append(options, strings.ToLower(name)+"="+o.Extra[name])
+	}
+
+	return fmt.Sprintf(`WITH "%s"`, strings.Join(options, ","))
+}
+
+// Option names and values can't break out of the quoted WITH clause
+func isOptionText(text string) bool {
+	if text == "" {
+		return false
+	}
+
+	for _, r := range text {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// A table created by Client.CreateTable
+type Table struct {
+	// Name is SCHEMA.TABLE as the analyzer reads it
+	Name    string
+	Options TableOptions
+
+	// OwnerBiscuit grants every table operation. Minted when TableOptions.OwnerKey is set, it doesn't expire
+	OwnerBiscuit string
+	Capabilities []authorization.SxTBiscuitStruct
+}
+
+// Operations granted by owner biscuits
+var ownerOperations = []string{"ddl_create", "ddl_alter", "ddl_drop", "dml_insert", "dml_update", "dml_merge", "dml_delete", "dql_select"}
+
+// Create a table from a CREATE TABLE statement without WITH clause, adding the options' WITH clause.
+// With an owner key, the owner biscuit is minted first, sent with the statement, recorded in the ledger and
+// registered with the client biscuits when they are set
+func (c *Client) CreateTable(ctx context.Context, sqlText string, options TableOptions) (*Table, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	sqlText = strings.TrimRight(strings.TrimSpace(sqlText), ";")
+	statement, err := sqlparser.Analyze(sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	if statement.Operation != "ddl_create" || statement.Object != "TABLE" {
+		return nil, errors.New("CreateTable expects a CREATE TABLE statement")
+	}
+
+	if hasTopLevelKeyword(statement.Tokens[1:], "WITH") {
+		return nil, errors.New("CREATE TABLE already has a WITH clause, give the options in TableOptions")
+	}
+
+	table := &Table{Name: statement.Target.String(), Options: options}
+	sqlText = sqlText + " " + options.withClause()
+
+	if options.OwnerKey == nil {
+		return table, c.DDL(ctx, sqlText, nil)
+	}
+
+	if c.Preflight != nil {
+		if err := c.Preflight(sqlText); err != nil {
+			return nil, err
+		}
+	}
+
+	resource := strings.ToLower(table.Name)
+	for _, operation := range ownerOperations {
+		table.Capabilities = append(table.Capabilities, authorization.SxTBiscuitStruct{Operation: operation, Resource: resource})
+	}
+
+	if c.Ledger != nil {
+		table.OwnerBiscuit, err = authorization.CreateTrackedBiscuitToken(c.Ledger, "table owner: "+table.Name, table.Capabilities, &options.OwnerKey, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var status bool
+		table.OwnerBiscuit, status = authorization.CreateBiscuitTokenWithExpiry(table.Capabilities, &options.OwnerKey, time.Time{})
+		if !status {
+			return nil, errors.New("unable to mint the owner biscuit")
+		}
+	}
+
+	postBody, _ := json.Marshal(map[string]interface{}{
+		"biscuits": []string{table.OwnerBiscuit},
+		"sqlText":  sqlText,
+	})
+	if _, err := c.execute(ctx, "ddl", postBody); err != nil {
+		return nil, err
+	}
+	invalidateDiscovery(sqlText)
+
+	if c.Biscuits != nil {
+		if err := c.Biscuits.Register(table.OwnerBiscuit, table.Capabilities, time.Time{}); err != nil {
+			return table, err
+		}
+	}
+
+	return table, nil
+}
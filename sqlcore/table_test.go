@@ -0,0 +1,78 @@
+package sqlcore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestTableOptionsValidate(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+
+	invalid := []TableOptions{
+		{AccessType: "ALL", PublicKey: publicKey},
+		{AccessType: AccessPermissioned},
+		{AccessType: AccessPermissioned, PublicKey: publicKey[:10]},
+		{AccessType: AccessPermissioned, PublicKey: otherKey, OwnerKey: privateKey},
+		{AccessType: AccessPermissioned, PublicKey: publicKey, Extra: map[string]string{"access_type": "public_read"}},
+		{AccessType: AccessPermissioned, PublicKey: publicKey, Extra: map[string]string{"x": `1",y="2`}},
+	}
+
+	for _, options := range invalid {
+		if err := options.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", options)
+		}
+	}
+
+	if errMsg, status := CreateTable("CREATE TABLE ETH.T (ID INT)", "ALL", "test", nil, publicKey); status || errMsg == "" {
+		t.Error("expected CreateTable to reject the ALL access type")
+	}
+
+	options := TableOptions{AccessType: AccessPublicRead, OwnerKey: privateKey, Immutable: true, Extra: map[string]string{"Retention": "30d"}}
+	if err := options.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := fmt.Sprintf(`WITH "public_key=%x,access_type=public_read,immutable=true,retention=30d"`, publicKey)
+	if options.withClause() != expected {
+		t.Errorf("unexpected WITH clause %s", options.withClause())
+	}
+}
+
+func TestClientCreateTable(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	transport := &recordingTransport{}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	registry, _ := NewBiscuitRegistry(nil)
+	client.Biscuits = registry
+
+	table, err := client.CreateTable(context.Background(), "CREATE TABLE ETH.T (ID INT PRIMARY KEY);", TableOptions{AccessType: AccessPermissioned, OwnerKey: privateKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := fmt.Sprintf(`CREATE TABLE ETH.T (ID INT PRIMARY KEY) WITH "public_key=%x,access_type=permissioned"`, publicKey)
+	if transport.statements[0] != expected {
+		t.Errorf("unexpected statement %s", transport.statements[0])
+	}
+
+	if table.Name != "ETH.T" || table.OwnerBiscuit == "" || len(table.Capabilities) != len(ownerOperations) || table.Capabilities[0].Resource != "eth.t" {
+		t.Errorf("unexpected table %+v", table)
+	}
+
+	if biscuits, err := registry.SelectCapabilities(table.Capabilities[3:4]); err != nil || biscuits[0] != table.OwnerBiscuit {
+		t.Errorf("expected the owner biscuit to be registered, got %v %v", biscuits, err)
+	}
+
+	for _, sqlText := range []string{"CREATE SCHEMA ETH", "DROP TABLE ETH.T", `CREATE TABLE ETH.T (ID INT) WITH "access_type=public_read"`} {
+		if _, err := client.CreateTable(context.Background(), sqlText, TableOptions{AccessType: AccessPermissioned, PublicKey: publicKey}); err == nil {
+			t.Errorf("%s: expected an error", sqlText)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Table described by a Go struct, see DefineTable
+type TableDef struct {
+	// Name is SCHEMA.TABLE
+	Name       string
+	Columns    []ColumnDef
+	PrimaryKey []string
+}
+
+type ColumnDef struct {
+	Name string
+
+	// Type is the SQL type, e.g. BIGINT or DECIMAL(18,2)
+	Type     string
+	Nullable bool
+}
+
+// Types of Go values that can be NULL
+var nullableTypes = map[reflect.Type]string{
+	reflect.TypeOf(sql.NullString{}):  "VARCHAR",
+	reflect.TypeOf(sql.NullInt64{}):   "BIGINT",
+	reflect.TypeOf(sql.NullInt32{}):   "INT",
+	reflect.TypeOf(sql.NullFloat64{}): "DOUBLE",
+	reflect.TypeOf(sql.NullBool{}):    "BOOLEAN",
+	reflect.TypeOf(sql.NullTime{}):    "TIMESTAMP",
+}
+
+var (
+	bigIntType     = reflect.TypeOf(big.Int{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Describe a table by the fields of struct T, mapped to columns like QueryInto and BulkInsert map them.
+// The sxt tag takes options after the column name:
+//
+//	ID    int64           `sxt:"ID,pk"`
+//	Price *big.Rat        `sxt:"PRICE,type=DECIMAL(18,2)"`
+//	Note  string          `sxt:",null"`
+//	Email sql.NullString  `sxt:"EMAIL,type=VARCHAR(320)"`
+//
+// Types come from the Go types: integers are BIGINT (INT and SMALLINT for int32 and int16), floats DOUBLE or REAL,
+// strings VARCHAR, time.Time TIMESTAMP and []byte VARBINARY. *big.Rat fields need a type option.
+// Pointer and sql.Null* fields are nullable, other fields NOT NULL unless tagged null. Primary key columns are never nullable
+func DefineTable[T any](table string) (*TableDef, error) {
+	if !isTableName(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	structType := reflect.TypeOf((*T)(nil)).Elem()
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", structType)
+	}
+
+	fields := structFields(structType)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return indexLess(fields[names[i]].index, fields[names[j]].index)
+	})
+
+	def := &TableDef{Name: table}
+	for _, name := range names {
+		field := structType.FieldByIndex(fields[name].index)
+		options, err := columnOptions(field.Tag.Get("sxt"))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		columnType, nullable, err := sqlType(field.Type)
+		if options.sqlType != "" {
+			columnType, err = options.sqlType, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		column := ColumnDef{Name: name, Type: columnType, Nullable: (nullable || options.null) && !options.pk}
+		def.Columns = append(def.Columns, column)
+		if options.pk {
+			def.PrimaryKey = append(def.PrimaryKey, name)
+		}
+	}
+
+	if len(def.Columns) == 0 {
+		return nil, fmt.Errorf("%s has no columns", structType)
+	}
+
+	return def, nil
+}
+
+type tagOptions struct {
+	pk      bool
+	null    bool
+	sqlType string
+}
+
+// Options after the column name of an sxt tag. Commas inside parentheses belong to the type
+func columnOptions(tag string) (options tagOptions, err error) {
+	var parts []string
+	depth, start := 0, 0
+	for idx, r := range tag {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, tag[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+	parts = append(parts, tag[start:])
+
+	for _, option := range parts[1:] {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == "pk":
+			options.pk = true
+		case option == "null":
+			options.null = true
+		case strings.HasPrefix(option, "type="):
+			options.sqlType = strings.ToUpper(strings.TrimSpace(option[len("type="):]))
+			if !isTypeName(options.sqlType) {
+				return options, fmt.Errorf("invalid type %q", options.sqlType)
+			}
+		default:
+			return options, fmt.Errorf("unknown sxt tag option %q", option)
+		}
+	}
+
+	return options, nil
+}
+
+// Types such as VARCHAR, VARCHAR(320) or DECIMAL(18, 2)
+func isTypeName(text string) bool {
+	name, params, hasParams := strings.Cut(text, "(")
+	if name == "" || !isOptionText(strings.TrimSpace(strings.ReplaceAll(name, " ", "_"))) {
+		return false
+	}
+
+	if !hasParams {
+		return true
+	}
+
+	if !strings.HasSuffix(params, ")") {
+		return false
+	}
+
+	for _, param := range strings.Split(strings.TrimSuffix(params, ")"), ",") {
+		param = strings.TrimSpace(param)
+		if param == "" || strings.Trim(param, "0123456789") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SQL type of a Go type, and if it can hold NULL
+func sqlType(goType reflect.Type) (columnType string, nullable bool, err error) {
+	if columnType, ok := nullableTypes[goType]; ok {
+		return columnType, true, nil
+	}
+
+	if goType.Kind() == reflect.Ptr {
+		columnType, _, err := sqlType(goType.Elem())
+		return columnType, true, err
+	}
+
+	switch goType {
+	case timeType:
+		return "TIMESTAMP", false, nil
+	case bigIntType:
+		return "DECIMAL(75,0)", false, nil
+	case ratType:
+		return "", false, fmt.Errorf("%s needs a type option, e.g. type=DECIMAL(18,2)", goType)
+	case rawMessageType:
+		return "VARCHAR", true, nil
+	}
+
+	switch goType.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN", false, nil
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT", false, nil
+	case reflect.Int32, reflect.Uint16:
+		return "INT", false, nil
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "BIGINT", false, nil
+	case reflect.Uint, reflect.Uint64:
+		return "DECIMAL(20,0)", false, nil
+	case reflect.Float32:
+		return "REAL", false, nil
+	case reflect.Float64:
+		return "DOUBLE", false, nil
+	case reflect.String:
+		return "VARCHAR", false, nil
+	case reflect.Slice:
+		if goType.Elem().Kind() == reflect.Uint8 {
+			return "VARBINARY", true, nil
+		}
+	}
+
+	return "", false, fmt.Errorf("%s has no SQL type, use a type option", goType)
+}
+
+// Column definition as written in CREATE TABLE and ALTER TABLE ADD
+func (c ColumnDef) definition() string {
+	if c.Nullable {
+		return quoteIdentifier(c.Name) + " " + c.Type
+	}
+
+	return quoteIdentifier(c.Name) + " " + c.Type + " NOT NULL"
+}
+
+// CREATE TABLE statement of the definition, without WITH clause
+func (d *TableDef) CreateSQL() string {
+	definitions := make([]string, 0, len(d.Columns)+1)
+	for _, column := range d.Columns {
+		definitions = append(definitions, column.definition())
+	}
+
+	if len(d.PrimaryKey) > 0 {
+		keys := make([]string, len(d.PrimaryKey))
+		for idx, key := range d.PrimaryKey {
+			keys[idx] = quoteIdentifier(key)
+		}
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (%s)", d.Name, strings.Join(definitions, ", "))
+}
+
+// Create the table of a definition with gateway options, see Client.CreateTable
+func (c *Client) CreateTableDef(ctx context.Context, def *TableDef, options TableOptions) (*Table, error) {
+	return c.CreateTable(ctx, def.CreateSQL(), options)
+}
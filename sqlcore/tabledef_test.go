@@ -0,0 +1,117 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type defAudit struct {
+	Created time.Time
+}
+
+type defUser struct {
+	defAudit
+	ID      int64          `sxt:"ID,pk"`
+	Email   sql.NullString `sxt:"EMAIL,type=VARCHAR(320)"`
+	Balance *big.Rat       `sxt:"BALANCE,type=DECIMAL(18, 2)"`
+	Age     int32
+	Note    string `sxt:",null"`
+	Secret  string `sxt:"-"`
+}
+
+func TestDefineTable(t *testing.T) {
+	def, err := DefineTable[defUser]("ETH.USERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "CREATE TABLE ETH.USERS (CREATED TIMESTAMP NOT NULL, ID BIGINT NOT NULL, EMAIL VARCHAR(320), BALANCE DECIMAL(18, 2), AGE INT NOT NULL, NOTE VARCHAR, PRIMARY KEY (ID))"
+	if def.CreateSQL() != expected {
+		t.Errorf("unexpected DDL %s", def.CreateSQL())
+	}
+
+	if _, err := DefineTable[struct{ Amount big.Rat }]("ETH.T"); err == nil {
+		t.Error("expected big.Rat without a type to be rejected")
+	}
+
+	if _, err := DefineTable[struct {
+		ID int64 `sxt:"ID,primary"`
+	}]("ETH.T"); err == nil {
+		t.Error("expected an unknown tag option to be rejected")
+	}
+
+	if _, err := DefineTable[struct {
+		ID int64 `sxt:"ID,type=INT; DROP TABLE X"`
+	}]("ETH.T"); err == nil {
+		t.Error("expected an invalid type to be rejected")
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	age := liveColumn{Column: "AGE", DataType: "INTEGER"}
+	defer func(previous func(string) ([]liveColumn, []string, error)) { liveTable = previous }(liveTable)
+	liveTable = func(table string) ([]liveColumn, []string, error) {
+		return []liveColumn{
+			{Column: "CREATED", DataType: "TIMESTAMP"},
+			{Column: "ID", DataType: "BIGINT"},
+			{Column: "EMAIL", DataType: "CHARACTER VARYING(320)", Nullable: true},
+			{Column: "BALANCE", DataType: "NUMERIC", Precision: 18, Scale: 2, Nullable: true},
+			age,
+			{Column: "NOTE", DataType: "BIGINT", Nullable: true},
+			{Column: "LEGACY", DataType: "VARCHAR", Nullable: true},
+		}, []string{"ID"}, nil
+	}
+
+	def, _ := DefineTable[defUser]("ETH.USERS")
+	client := NewClient("test")
+	transport := &recordingTransport{}
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	plan, err := client.PlanSync(context.Background(), def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statements []string
+	for _, change := range plan.Changes {
+		statements = append(statements, change.SQL)
+	}
+
+	expected := []string{
+		"ALTER TABLE ETH.USERS DROP COLUMN LEGACY",
+		"ALTER TABLE ETH.USERS DROP COLUMN NOTE",
+		"ALTER TABLE ETH.USERS ADD COLUMN NOTE VARCHAR",
+	}
+	if !reflect.DeepEqual(statements, expected) || len(plan.Unsupported) != 0 || !plan.Destructive() {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+
+	if err := client.ApplySync(context.Background(), plan, SyncOptions{}); !errors.Is(err, ErrDestructiveChange) || len(transport.statements) != 0 {
+		t.Errorf("expected the destructive plan to be refused, got %v", err)
+	}
+
+	if err := client.ApplySync(context.Background(), plan, SyncOptions{AllowDestructive: true}); err != nil || !reflect.DeepEqual(transport.statements, expected) {
+		t.Errorf("expected the plan to be applied, got %v: %v", transport.statements, err)
+	}
+
+	// A NOT NULL column added again would fail on a populated table after its data is dropped
+	age.DataType = "BIGINT"
+	plan, _ = client.PlanSync(context.Background(), def)
+	if len(plan.Unsupported) != 1 || len(plan.Changes) != 3 {
+		t.Errorf("expected the NOT NULL rewrite to be unsupported, got %+v", plan)
+	}
+
+	age.DataType = "INT"
+	def.PrimaryKey = []string{"ID", "EMAIL"}
+	plan, _ = client.PlanSync(context.Background(), def)
+	if len(plan.Unsupported) != 1 || client.ApplySync(context.Background(), plan, SyncOptions{AllowDestructive: true}) == nil {
+		t.Errorf("expected the primary key change to be unsupported, got %+v", plan)
+	}
+}
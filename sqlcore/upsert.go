@@ -0,0 +1,453 @@
+package sqlcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+)
+
+// How Upsert writes rows
+type UpsertStrategy int
+
+const (
+	// One MERGE statement per batch
+	UpsertMerge UpsertStrategy = iota
+
+	// Select the existing keys of a batch, UPDATE the rows found and INSERT the others.
+	// For gateways or tables where MERGE isn't available. Not atomic, and needs dql_select on the table
+	UpsertSelectThenWrite
+)
+
+// Settings for Client.UpsertWithOptions
+type UpsertOptions struct {
+	// KeyColumns identify rows. Found with discovery.ListTablePrimaryKey when empty
+	KeyColumns []string
+
+	// MaxRows is the most rows per batch, DefaultBulkMaxRows when zero
+	MaxRows int
+
+	Strategy UpsertStrategy
+
+	// Columns written from maps, in order. Defaults to the sorted keys of the first map
+	Columns []string
+}
+
+// Outcome of an upsert. Inserted and Updated are only known when Counted is set,
+// that is when the gateway reported them for every batch or the select then write strategy was used
+type UpsertResult struct {
+	Chunks   ChunkResults
+	Inserted int
+	Updated  int
+	Counted  bool
+}
+
+// Batches that failed, to be retried
+func (r *UpsertResult) Failed() []ChunkResult {
+	return r.Chunks.Failed()
+}
+
+// First batch error, nil when every batch was written
+func (r *UpsertResult) Err() error {
+	return r.Chunks.Err()
+}
+
+// Looks up the primary key columns of a table, replaced in tests
+var primaryKeyColumns = discoverPrimaryKey
+
+// Insert rows, or update the rows whose key columns match, with MERGE statements.
+// rows are given as for BulkInsert. keyColumns are found with discovery when omitted
+func (c *Client) Upsert(ctx context.Context, table string, rows interface{}, keyColumns ...string) (*UpsertResult, error) {
+	return c.UpsertWithOptions(ctx, table, rows, UpsertOptions{KeyColumns: keyColumns})
+}
+
+// Upsert with batching and strategy settings. The returned error covers bad input and key discovery,
+// batch failures are reported in the result
+func (c *Client) UpsertWithOptions(ctx context.Context, table string, rows interface{}, options UpsertOptions) (*UpsertResult, error) {
+	if !isTableName(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	source, err := rowSourceOf(rows, options.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.MaxRows <= 0 {
+		options.MaxRows = DefaultBulkMaxRows
+	}
+
+	keyColumns := options.KeyColumns
+	if len(keyColumns) == 0 {
+		keyColumns, err = primaryKeyColumns(table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plan, err := newUpsertPlan(table, source.Columns(), keyColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Encryption != nil {
+		if err := c.Encryption.checkKeys(table, keyColumns); err != nil {
+			return nil, err
+		}
+		source = c.Encryption.source(ctx, table, source)
+	}
+
+	result := &UpsertResult{Counted: true}
+	offset := 0
+	for ctx.Err() == nil {
+		batch, err := readBatch(source, options.MaxRows, len(plan.columns))
+		if err != nil {
+			return result, fmt.Errorf("row %d: %w", offset+len(batch), err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		var inserted, updated int
+		var counted bool
+		if options.Strategy == UpsertSelectThenWrite {
+			inserted, updated, err = c.selectThenWrite(ctx, plan, batch)
+			counted = err == nil
+		} else {
+			inserted, updated, counted, err = c.merge(ctx, plan, batch)
+		}
+
+		result.Chunks = append(result.Chunks, ChunkResult{Offset: offset, Rows: len(batch), Err: err})
+		result.Inserted += inserted
+		result.Updated += updated
+		if !counted {
+			result.Counted = false
+		}
+
+		offset += len(batch)
+	}
+
+	if !result.Counted {
+		result.Inserted, result.Updated = 0, 0
+	}
+
+	return result, ctx.Err()
+}
+
+// Columns of an upsert with the key columns found among them
+type upsertPlan struct {
+	table     string
+	columns   []string
+	isKey     []bool
+	keys      []int
+	resources []string
+}
+
+func newUpsertPlan(table string, columns, keyColumns []string) (*upsertPlan, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("rows have no columns")
+	}
+
+	plan := &upsertPlan{table: table, isKey: make([]bool, len(columns)), resources: []string{table}}
+	for _, column := range columns {
+		plan.columns = append(plan.columns, quoteIdentifier(column))
+	}
+
+	for _, key := range keyColumns {
+		found := false
+		for idx, column := range columns {
+			if strings.EqualFold(column, key) {
+				plan.isKey[idx] = true
+				plan.keys = append(plan.keys, idx)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("key column %s is not in the rows", key)
+		}
+	}
+
+	if len(plan.keys) == 0 {
+		return nil, fmt.Errorf("no key columns for %s", table)
+	}
+
+	return plan, nil
+}
+
+// MERGE INTO t AS TGT USING (VALUES ...) AS SRC (cols) ON keys
+// WHEN MATCHED THEN UPDATE SET ... WHEN NOT MATCHED THEN INSERT ...
+func (p *upsertPlan) mergeStatement(tuples []string) string {
+	var on, set, sourceColumns []string
+	for idx, column := range p.columns {
+		sourceColumns = append(sourceColumns, "SRC."+column)
+		if p.isKey[idx] {
+			on = append(on, fmt.Sprintf("TGT.%s = SRC.%s", column, column))
+		} else {
+			set = append(set, fmt.Sprintf("%s = SRC.%s", column, column))
+		}
+	}
+
+	columns := strings.Join(p.columns, ", ")
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "MERGE INTO %s AS TGT USING (VALUES %s) AS SRC (%s) ON %s", p.table, strings.Join(tuples, ", "), columns, strings.Join(on, " AND "))
+	if len(set) > 0 {
+		fmt.Fprintf(&builder, " WHEN MATCHED THEN UPDATE SET %s", strings.Join(set, ", "))
+	}
+	fmt.Fprintf(&builder, " WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", columns, strings.Join(sourceColumns, ", "))
+
+	return builder.String()
+}
+
+func (c *Client) merge(ctx context.Context, plan *upsertPlan, batch [][]string) (inserted, updated int, counted bool, err error) {
+	tuples := make([]string, len(batch))
+	for idx, literals := range batch {
+		tuples[idx] = "(" + strings.Join(literals, ", ") + ")"
+	}
+
+	data, err := c.dml(ctx, plan.mergeStatement(tuples), plan.resources)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	inserted, updated, counted = affectedRows(data)
+	return inserted, updated, counted, nil
+}
+
+func (c *Client) selectThenWrite(ctx context.Context, plan *upsertPlan, batch [][]string) (inserted, updated int, err error) {
+	keyColumns := make([]string, len(plan.keys))
+	for idx, key := range plan.keys {
+		keyColumns[idx] = plan.columns[key]
+	}
+
+	conditions := make([]string, len(batch))
+	for idx, literals := range batch {
+		conditions[idx] = plan.keyCondition(literals)
+	}
+
+	// Keys are compared as sent, encrypted keys are not decrypted
+	existing, err := c.query(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(keyColumns, ", "), plan.table, strings.Join(conditions, " OR ")), plan.resources, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Keys are compared normalized as the kind of their literals, e.g. 1.50 matches a returned 1.5
+	// and TIMESTAMP '2024-01-01 00:00:00' a returned 2024-01-01T00:00:00Z
+	kinds := make([]literalKind, len(plan.keys))
+	for idx, key := range plan.keys {
+		for _, literals := range batch {
+			if kind, _ := normalizeLiteral(literals[key]); kind != literalNull {
+				kinds[idx] = kind
+				break
+			}
+		}
+	}
+
+	found := map[string]bool{}
+	for _, row := range existing.Rows {
+		values := make([]string, len(plan.keys))
+		for idx, column := range keyColumns {
+			value, err := row.Value(strings.Trim(column, `"`))
+			if err != nil {
+				return 0, 0, err
+			}
+			values[idx] = normalizeValue(kinds[idx], value)
+		}
+		found[strings.Join(values, "\x00")] = true
+	}
+
+	var inserts []string
+	for _, literals := range batch {
+		values := make([]string, len(plan.keys))
+		for idx, key := range plan.keys {
+			_, values[idx] = normalizeLiteral(literals[key])
+		}
+
+		if !found[strings.Join(values, "\x00")] {
+			inserts = append(inserts, "("+strings.Join(literals, ", ")+")")
+			continue
+		}
+
+		var set []string
+		for idx, column := range plan.columns {
+			if !plan.isKey[idx] {
+				set = append(set, column+" = "+literals[idx])
+			}
+		}
+
+		if len(set) > 0 {
+			statement := fmt.Sprintf("UPDATE %s SET %s WHERE %s", plan.table, strings.Join(set, ", "), plan.keyCondition(literals))
+			if err := c.DML(ctx, statement, plan.resources); err != nil {
+				return inserted, updated, err
+			}
+		}
+		updated++
+	}
+
+	if len(inserts) > 0 {
+		statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", plan.table, strings.Join(plan.columns, ", "), strings.Join(inserts, ", "))
+		if err := c.DML(ctx, statement, plan.resources); err != nil {
+			return inserted, updated, err
+		}
+		inserted = len(inserts)
+	}
+
+	return inserted, updated, nil
+}
+
+// (K1 = v1 AND K2 = v2) for a row of literals
+func (p *upsertPlan) keyCondition(literals []string) string {
+	conditions := make([]string, len(p.keys))
+	for idx, key := range p.keys {
+		conditions[idx] = p.columns[key] + " = " + literals[key]
+	}
+
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+// Read up to size rows rendered as literals
+func readBatch(source RowSource, size, columns int) (batch [][]string, err error) {
+	for len(batch) < size {
+		values, err := source.NextRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return batch, err
+		}
+
+		if len(values) != columns {
+			return batch, fmt.Errorf("%d values for %d columns", len(values), columns)
+		}
+
+		literals := make([]string, len(values))
+		for idx, value := range values {
+			if literals[idx], err = FormatLiteral(value); err != nil {
+				return batch, err
+			}
+		}
+		batch = append(batch, literals)
+	}
+
+	return batch, nil
+}
+
+// Row counts of a DML response such as [{"INSERTED": 2, "UPDATED": 1}]
+func affectedRows(data []byte) (inserted, updated int, counted bool) {
+	resultSet, err := DecodeResultSet(data)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	for _, row := range resultSet.Rows {
+		if value, err := row.Int64("INSERTED"); err == nil {
+			inserted += int(value)
+			counted = true
+		}
+		if value, err := row.Int64("UPDATED"); err == nil {
+			updated += int(value)
+			counted = true
+		}
+	}
+
+	return inserted, updated, counted
+}
+
+// Primary key columns of a SCHEMA.TABLE from the discovery APIs
+func discoverPrimaryKey(table string) (columns []string, err error) {
+	columns, err = listPrimaryKey(table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s has no primary key, give key columns explicitly", table)
+	}
+
+	return columns, nil
+}
+
+// Primary key columns of a SCHEMA.TABLE, none when it has no primary key
+func listPrimaryKey(table string) (columns []string, err error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("key columns of %s can't be discovered, give them explicitly", table)
+	}
+
+	keys, errMsg, status := discovery.ListTablePrimaryKey(parts[0], parts[1])
+	if !status {
+		return nil, fmt.Errorf("unable to discover the primary key of %s: %s", table, errMsg)
+	}
+
+	for _, key := range keys {
+		columns = append(columns, key.Column)
+	}
+
+	return columns, nil
+}
+
+// Kind of a SQL literal, to compare it with values returned by the gateway
+type literalKind int
+
+const (
+	literalOther literalKind = iota
+	literalNull
+	literalNumber
+	literalString
+	literalTimestamp
+)
+
+// Kind and comparable form of a literal written by FormatLiteral: numbers as exact fractions,
+// timestamps in UTC and strings unquoted. Other literals are kept as written
+func normalizeLiteral(literal string) (literalKind, string) {
+	switch {
+	case literal == "NULL":
+		return literalNull, literal
+	case strings.HasPrefix(literal, "TIMESTAMP '") && strings.HasSuffix(literal, "'"):
+		if parsed, ok := parseTimestamp(literal[len("TIMESTAMP '") : len(literal)-1]); ok {
+			return literalTimestamp, parsed.UTC().Format(time.RFC3339Nano)
+		}
+	case len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'':
+		return literalString, strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	case numberPattern.MatchString(literal):
+		if number, ok := new(big.Rat).SetString(literal); ok {
+			return literalNumber, number.RatString()
+		}
+	}
+
+	return literalOther, literal
+}
+
+// Comparable form of a value returned by the gateway, read as a literal of a kind
+func normalizeValue(kind literalKind, value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	text := fmt.Sprint(value)
+	switch kind {
+	case literalNumber:
+		if number, ok := new(big.Rat).SetString(text); ok {
+			return number.RatString()
+		}
+	case literalTimestamp:
+		if parsed, ok := parseTimestamp(text); ok {
+			return parsed.UTC().Format(time.RFC3339Nano)
+		}
+	case literalString:
+		return text
+	}
+
+	literal, err := FormatLiteral(value)
+	if err != nil {
+		return text
+	}
+	return literal
+}
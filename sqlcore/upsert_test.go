@@ -0,0 +1,106 @@
+package sqlcore
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpsertMerge(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	transport := &recordingTransport{respond: func(string) string { return `[{"INSERTED": 1, "UPDATED": 1}]` }}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	discovered := ""
+	primaryKeyColumns = func(table string) ([]string, error) {
+		discovered = table
+		return []string{"ID"}, nil
+	}
+	defer func() { primaryKeyColumns = discoverPrimaryKey }()
+
+	rows := []map[string]interface{}{{"ID": 1, "TEST": "a"}, {"ID": 2, "TEST": "b"}, {"ID": 3, "TEST": "c"}}
+	result, err := client.UpsertWithOptions(context.Background(), "ETH.T", rows, UpsertOptions{MaxRows: 2})
+	if err != nil || result.Err() != nil {
+		t.Fatal(err, result.Err())
+	}
+
+	if discovered != "ETH.T" {
+		t.Errorf("primary key not discovered, got %q", discovered)
+	}
+
+	expected := "MERGE INTO ETH.T AS TGT USING (VALUES (1, 'a'), (2, 'b')) AS SRC (ID, TEST) ON TGT.ID = SRC.ID " +
+		"WHEN MATCHED THEN UPDATE SET TEST = SRC.TEST WHEN NOT MATCHED THEN INSERT (ID, TEST) VALUES (SRC.ID, SRC.TEST)"
+	if len(transport.statements) != 2 || transport.statements[0] != expected {
+		t.Errorf("unexpected statements %v", transport.statements)
+	}
+
+	if !result.Counted || result.Inserted != 2 || result.Updated != 2 {
+		t.Errorf("unexpected counts %+v", result)
+	}
+}
+
+func TestUpsertSelectThenWrite(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	transport := &recordingTransport{respond: func(sqlText string) string {
+		if strings.HasPrefix(sqlText, "SELECT") {
+			return `[{"ID": 2}]`
+		}
+		return "[]"
+	}}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	rows := []map[string]interface{}{{"ID": 1, "TEST": "a"}, {"ID": 2, "TEST": "b"}}
+	result, err := client.UpsertWithOptions(context.Background(), "ETH.T", rows, UpsertOptions{KeyColumns: []string{"id"}, Strategy: UpsertSelectThenWrite})
+	if err != nil || result.Err() != nil {
+		t.Fatal(err, result.Err())
+	}
+
+	expected := []string{
+		"SELECT ID FROM ETH.T WHERE (ID = 1) OR (ID = 2)",
+		"UPDATE ETH.T SET TEST = 'b' WHERE (ID = 2)",
+		"INSERT INTO ETH.T (ID, TEST) VALUES (1, 'a')",
+	}
+	if strings.Join(transport.statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statements %v", transport.statements)
+	}
+
+	if result.Inserted != 1 || result.Updated != 1 {
+		t.Errorf("unexpected counts %+v", result)
+	}
+
+	if _, err := client.Upsert(context.Background(), "ETH.T", rows, "MISSING"); err == nil {
+		t.Error("expected an unknown key column error")
+	}
+}
+
+func TestUpsertSelectThenWriteNormalizedKeys(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	transport := &recordingTransport{respond: func(sqlText string) string {
+		if strings.HasPrefix(sqlText, "SELECT") {
+			return `[{"DAY": "2024-03-01T00:00:00Z", "PRICE": 1.5}]`
+		}
+		return "[]"
+	}}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := []map[string]interface{}{
+		{"PRICE": big.NewRat(3, 2), "DAY": day, "TEST": "a"},
+		{"PRICE": json.Number("1.50"), "DAY": day.Add(24 * time.Hour), "TEST": "b"},
+	}
+	result, err := client.UpsertWithOptions(context.Background(), "ETH.T", rows, UpsertOptions{KeyColumns: []string{"PRICE", "DAY"}, Strategy: UpsertSelectThenWrite})
+	if err != nil || result.Err() != nil {
+		t.Fatal(err, result.Err())
+	}
+
+	if result.Inserted != 1 || result.Updated != 1 {
+		t.Errorf("expected the existing row to be updated, got %+v: %v", result, transport.statements)
+	}
+}
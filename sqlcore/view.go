@@ -0,0 +1,247 @@
+package sqlcore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+// Views matching a name, from discovery.ListViews. Replaced in tests
+var listViews = discoverViews
+
+// A parameter of a parameterized view, written :NAME in the view SQL
+type ViewParameter = discovery.ViewParameter
+
+// View to create with Client.CreateView
+type ViewDef struct {
+	// Name is SCHEMA.VIEW
+	Name string
+
+	// SQL is the SELECT statement of the view
+	SQL         string
+	Description string
+
+	// Parameters make a parameterized view. Every :NAME placeholder of the SQL must be declared
+	Parameters []ViewParameter
+}
+
+// View metadata from the discovery APIs
+type View = discovery.View
+
+// Check the name, the SELECT statement and that parameters match the placeholders of the SQL
+func (d *ViewDef) Validate() error {
+	if !isViewName(d.Name) {
+		return fmt.Errorf("invalid view name %q", d.Name)
+	}
+
+	statement, err := sqlparser.Analyze(d.SQL)
+	if err != nil {
+		return fmt.Errorf("view %s: %w", d.Name, err)
+	}
+	if statement.Operation != "dql_select" {
+		return fmt.Errorf("view %s: SQL must be a SELECT statement", d.Name)
+	}
+
+	declared := map[string]bool{}
+	for _, parameter := range d.Parameters {
+		name := strings.ToUpper(parameter.Name)
+		if declared[name] {
+			return fmt.Errorf("view %s: parameter %s is declared twice", d.Name, parameter.Name)
+		}
+		if !isTypeName(strings.ToUpper(parameter.Type)) {
+			return fmt.Errorf("view %s: parameter %s has invalid type %q", d.Name, parameter.Name, parameter.Type)
+		}
+		declared[name] = true
+	}
+
+	placeholders := map[string]bool{}
+	for _, token := range statement.Tokens {
+		if token.Type != sqlparser.Param {
+			continue
+		}
+		if !strings.HasPrefix(token.Text, ":") {
+			return fmt.Errorf("view %s: %s: parameters are written :NAME", d.Name, token.Pos)
+		}
+
+		name := strings.ToUpper(token.Text[1:])
+		if !declared[name] {
+			return fmt.Errorf("view %s: %s: parameter %s is not declared", d.Name, token.Pos, token.Text[1:])
+		}
+		placeholders[name] = true
+	}
+
+	for _, parameter := range d.Parameters {
+		if !placeholders[strings.ToUpper(parameter.Name)] {
+			return fmt.Errorf("view %s: parameter %s is not used", d.Name, parameter.Name)
+		}
+	}
+
+	return nil
+}
+
+// CREATE VIEW statement of the definition
+func (d *ViewDef) CreateSQL() string {
+	return fmt.Sprintf("CREATE VIEW %s AS %s", d.Name, strings.TrimRight(strings.TrimSpace(d.SQL), ";"))
+}
+
+// Capabilities creating the view needs: ddl_create on the view and dql_select on the tables it reads
+func (d *ViewDef) Capabilities() ([]authorization.SxTBiscuitStruct, error) {
+	statement, err := sqlparser.Analyze(d.CreateSQL())
+	if err != nil {
+		return nil, err
+	}
+
+	return statement.Capabilities(), nil
+}
+
+// Capabilities querying a view needs. Readers don't need access to the tables behind it
+func ViewCapabilities(name string) []authorization.SxTBiscuitStruct {
+	return []authorization.SxTBiscuitStruct{{Operation: "dql_select", Resource: strings.ToLower(name)}}
+}
+
+// Create a view. Views without parameters are created with a CREATE VIEW statement,
+// parameterized views through the gateway views API. Biscuits are minted or selected for Capabilities
+func (c *Client) CreateView(ctx context.Context, def *ViewDef) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+
+	if len(def.Parameters) == 0 {
+		return c.DDL(ctx, def.CreateSQL(), nil)
+	}
+
+	capabilities, err := def.Capabilities()
+	if err != nil {
+		return err
+	}
+
+	biscuitArray, err := c.biscuitsFor(capabilities)
+	if err != nil {
+		return err
+	}
+
+	parameters := make([]ViewParameter, len(def.Parameters))
+	for idx, parameter := range def.Parameters {
+		parameters[idx] = ViewParameter{Name: strings.ToUpper(parameter.Name), Type: strings.ToUpper(parameter.Type)}
+	}
+
+	postBody, _ := json.Marshal(map[string]interface{}{
+		"biscuits":    biscuitArray,
+		"viewName":    def.Name,
+		"viewText":    strings.TrimRight(strings.TrimSpace(def.SQL), ";"),
+		"description": def.Description,
+		"parameters":  parameters,
+	})
+
+	if _, err := c.execute(ctx, "views", postBody); err != nil {
+		return err
+	}
+
+	schema, _, _ := strings.Cut(strings.ToUpper(def.Name), ".")
+	discovery.Invalidate(schema, "")
+	return nil
+}
+
+// Drop a view, parameterized or not
+func (c *Client) DropView(ctx context.Context, name string) error {
+	if !isViewName(name) {
+		return fmt.Errorf("invalid view name %q", name)
+	}
+
+	return c.DDL(ctx, "DROP VIEW "+name, nil)
+}
+
+// Find a view's definition, parameters and owner with the discovery APIs
+func (c *Client) DescribeView(ctx context.Context, name string) (*View, error) {
+	if !isViewName(name) {
+		return nil, fmt.Errorf("invalid view name %q", name)
+	}
+
+	views, err := listViews(strings.ToUpper(name))
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range views {
+		if strings.EqualFold(views[idx].Name, name) {
+			return &views[idx], nil
+		}
+	}
+
+	return nil, fmt.Errorf("view %s doesn't exist", name)
+}
+
+// Query a view. Arguments of parameterized views are rendered as SQL literals by FormatLiteral
+// and sent to the gateway views API, which binds them to the view's :NAME placeholders.
+// Views without parameters are read with SELECT * and args must be empty
+func (c *Client) QueryView(ctx context.Context, name string, args map[string]interface{}) (*ResultSet, error) {
+	if !isViewName(name) {
+		return nil, fmt.Errorf("invalid view name %q", name)
+	}
+
+	if len(args) == 0 {
+		return c.Query(ctx, "SELECT * FROM "+name, nil)
+	}
+
+	names := make([]string, 0, len(args))
+	for argName := range args {
+		names = append(names, argName)
+	}
+	sort.Strings(names)
+
+	params := map[string]string{}
+	for _, argName := range names {
+		literal, err := FormatLiteral(args[argName])
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", argName, err)
+		}
+
+		key := strings.ToUpper(strings.TrimPrefix(argName, ":"))
+		if _, ok := params[key]; ok {
+			return nil, fmt.Errorf("parameter %s is given twice", argName)
+		}
+		params[key] = literal
+	}
+
+	biscuitArray, err := c.biscuitsFor(ViewCapabilities(name))
+	if err != nil {
+		return nil, err
+	}
+
+	postBody, _ := json.Marshal(map[string]interface{}{
+		"biscuits": biscuitArray,
+		"params":   params,
+	})
+
+	data, err := c.execute(ctx, "views/"+strings.ToUpper(name), postBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Encryption != nil {
+		return c.Encryption.DecodeResultSet(ctx, data)
+	}
+
+	return DecodeResultSet(data)
+}
+
+// Views are always SCHEMA.VIEW
+func isViewName(name string) bool {
+	return isTableName(name) && strings.Count(name, ".") == 1
+}
+
+func discoverViews(name string) (views []View, err error) {
+	views, errMsg, status := discovery.ListViews(name, discovery.AllViews)
+	if !status {
+		return nil, errors.New("unable to discover views: " + errMsg)
+	}
+
+	return views, nil
+}
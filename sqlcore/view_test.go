@@ -0,0 +1,124 @@
+package sqlcore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Records request paths and JSON bodies, answering with a fixed response
+type viewGateway struct {
+	paths    []string
+	bodies   []map[string]interface{}
+	response string
+}
+
+func (g *viewGateway) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body map[string]interface{}
+	json.NewDecoder(request.Body).Decode(&body)
+	g.paths = append(g.paths, request.URL.Path)
+	g.bodies = append(g.bodies, body)
+
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(g.response)), Header: http.Header{}}, nil
+}
+
+func TestViewDefValidate(t *testing.T) {
+	def := &ViewDef{
+		Name:       "ETH.RECENT",
+		SQL:        "SELECT B.ID FROM ETH.BLOCKS B JOIN ETH.TX T ON B.ID = T.ID WHERE B.N > :min_block",
+		Parameters: []ViewParameter{{Name: "MIN_BLOCK", Type: "BIGINT"}},
+	}
+	if err := def.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	capabilities, _ := def.Capabilities()
+	var operations []string
+	for _, capability := range capabilities {
+		operations = append(operations, capability.Operation+" "+capability.Resource)
+	}
+	if !reflect.DeepEqual(operations, []string{"ddl_create eth.recent", "dql_select eth.blocks", "dql_select eth.tx"}) {
+		t.Errorf("unexpected capabilities %v", operations)
+	}
+
+	invalid := []ViewDef{
+		{Name: "RECENT", SQL: "SELECT 1"},
+		{Name: "ETH.RECENT", SQL: "DELETE FROM ETH.BLOCKS"},
+		{Name: "ETH.RECENT", SQL: "SELECT * FROM ETH.BLOCKS WHERE N > :n"},
+		{Name: "ETH.RECENT", SQL: "SELECT * FROM ETH.BLOCKS WHERE N > ?", Parameters: []ViewParameter{{Name: "N", Type: "BIGINT"}}},
+		{Name: "ETH.RECENT", SQL: "SELECT * FROM ETH.BLOCKS", Parameters: []ViewParameter{{Name: "N", Type: "BIGINT"}}},
+		{Name: "ETH.RECENT", SQL: "SELECT * FROM ETH.BLOCKS WHERE N > :n", Parameters: []ViewParameter{{Name: "N", Type: "BIGINT; DROP"}}},
+	}
+	for _, def := range invalid {
+		if def.Validate() == nil {
+			t.Errorf("expected %+v to be rejected", def)
+		}
+	}
+}
+
+func TestClientViews(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+	ctx := context.Background()
+
+	_, privateKey, _ := ed25519.GenerateKey(nil)
+	gateway := &viewGateway{response: `[{"ID": 7}]`}
+	client := NewClient("test")
+	client.HTTPClient = &http.Client{Transport: gateway}
+	client.MintKey = privateKey
+
+	plain := &ViewDef{Name: "ETH.ALL_BLOCKS", SQL: "SELECT * FROM ETH.BLOCKS;"}
+	if err := client.CreateView(ctx, plain); err != nil || gateway.paths[0] != "/sql/ddl" || gateway.bodies[0]["sqlText"] != "CREATE VIEW ETH.ALL_BLOCKS AS SELECT * FROM ETH.BLOCKS" {
+		t.Errorf("unexpected plain view request %v %v: %v", gateway.paths, gateway.bodies, err)
+	}
+
+	parameterized := &ViewDef{
+		Name:       "ETH.RECENT",
+		SQL:        "SELECT * FROM ETH.BLOCKS WHERE N > :min_block",
+		Parameters: []ViewParameter{{Name: "min_block", Type: "bigint"}},
+	}
+	if err := client.CreateView(ctx, parameterized); err != nil || gateway.paths[1] != "/sql/views" || gateway.bodies[1]["viewName"] != "ETH.RECENT" {
+		t.Errorf("unexpected parameterized view request %v %v: %v", gateway.paths, gateway.bodies, err)
+	}
+	if biscuits, _ := gateway.bodies[1]["biscuits"].([]interface{}); len(biscuits) != 1 {
+		t.Errorf("expected a minted biscuit, got %v", gateway.bodies[1]["biscuits"])
+	}
+
+	resultSet, err := client.QueryView(ctx, "ETH.RECENT", map[string]interface{}{"min_block": 100, "label": "it's"})
+	if err != nil || len(resultSet.Rows) != 1 || gateway.paths[2] != "/sql/views/ETH.RECENT" {
+		t.Fatalf("unexpected view query %v: %v", gateway.paths, err)
+	}
+	expected := map[string]interface{}{"MIN_BLOCK": "100", "LABEL": "'it''s'"}
+	if !reflect.DeepEqual(gateway.bodies[2]["params"], expected) {
+		t.Errorf("unexpected params %v", gateway.bodies[2]["params"])
+	}
+
+	if _, err := client.QueryView(ctx, "ETH.ALL_BLOCKS", nil); err != nil || gateway.bodies[3]["sqlText"] != "SELECT * FROM ETH.ALL_BLOCKS" {
+		t.Errorf("unexpected plain view query %v: %v", gateway.bodies[3], err)
+	}
+
+	if err := client.DropView(ctx, "ETH.RECENT"); err != nil || gateway.bodies[4]["sqlText"] != "DROP VIEW ETH.RECENT" {
+		t.Errorf("unexpected drop %v: %v", gateway.bodies[4], err)
+	}
+
+	defer func(previous func(string) ([]View, error)) { listViews = previous }(listViews)
+	listViews = func(name string) ([]View, error) {
+		return []View{
+			{Name: "ETH.RECENT_TX", SQL: "SELECT * FROM ETH.TX"},
+			{Name: "ETH.RECENT", SQL: parameterized.SQL, Owner: "alice", Parameters: []ViewParameter{{Name: "MIN_BLOCK", Type: "BIGINT"}}},
+		}, nil
+	}
+
+	view, err := client.DescribeView(ctx, "eth.recent")
+	if err != nil || view.Owner != "alice" || len(view.Parameters) != 1 {
+		t.Errorf("unexpected view %+v: %v", view, err)
+	}
+
+	if _, err := client.DescribeView(ctx, "ETH.MISSING"); err == nil {
+		t.Error("expected a missing view error")
+	}
+}
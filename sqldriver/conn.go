@@ -0,0 +1,169 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+type conn struct {
+	client *sqlcore.Client
+}
+
+var (
+	_ driver.ConnBeginTx            = (*conn)(nil)
+	_ driver.ConnPrepareContext     = (*conn)(nil)
+	_ driver.ExecerContext          = (*conn)(nil)
+	_ driver.QueryerContext         = (*conn)(nil)
+	_ driver.NamedValueChecker      = (*conn)(nil)
+	_ driver.StmtExecContext        = (*stmt)(nil)
+	_ driver.StmtQueryContext       = (*stmt)(nil)
+	_ driver.RowsColumnTypeScanType = (*rows)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// Statements are only checked locally, SxT has no server side prepared statements
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if _, err := sqlparser.Tokenize(query); err != nil {
+		return nil, err
+	}
+
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, ErrTransactionsUnsupported
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return nil, ErrTransactionsUnsupported
+}
+
+// Values are passed through untouched, sqlcore.FormatLiteral decides what it accepts
+func (c *conn) CheckNamedValue(value *driver.NamedValue) error {
+	return nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	sqlText, err := bind(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	executed, err := c.client.Exec(ctx, sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	return result{executed}, nil
+}
+
+// Queries stream their rows. Other statements are run and return no rows
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	sqlText, err := bind(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	statement, err := sqlparser.Analyze(sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	if statement.Kind != sqlparser.KindDQL {
+		if _, err := c.client.Exec(ctx, sqlText); err != nil {
+			return nil, err
+		}
+		return &rows{}, nil
+	}
+
+	stream, err := c.client.Stream(ctx, sqlText, statement.Resources())
+	if err != nil {
+		return nil, err
+	}
+
+	return newRows(stream)
+}
+
+// Bind driver args with sqlcore.BindParams, named args by name and the others by position
+func bind(query string, args []driver.NamedValue) (string, error) {
+	if len(args) == 0 {
+		return sqlcore.BindParams(query)
+	}
+
+	values := make([]interface{}, len(args))
+	for idx, arg := range args {
+		if arg.Name != "" {
+			values[idx] = sql.Named(arg.Name, arg.Value)
+		} else {
+			values[idx] = arg.Value
+		}
+	}
+
+	return sqlcore.BindParams(query, values...)
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// The number of placeholders isn't checked by database/sql, BindParams checks it when binding
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for idx, arg := range args {
+		named[idx] = driver.NamedValue{Ordinal: idx + 1, Value: arg}
+	}
+
+	return named
+}
+
+type result struct {
+	executed *sqlcore.ExecResult
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return 0, ErrLastInsertIdUnsupported
+}
+
+func (r result) RowsAffected() (int64, error) {
+	if !r.executed.Counted {
+		return 0, ErrRowsAffectedUnknown
+	}
+
+	return int64(r.executed.RowsAffected), nil
+}
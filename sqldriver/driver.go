@@ -0,0 +1,168 @@
+// Package sqldriver is a database/sql driver for Space and Time, registered as "sxt".
+//
+//	db, err := sql.Open("sxt", "profile=<user id>")
+//	rows, err := db.QueryContext(ctx, "SELECT * FROM ETH.TESTTABLE103 WHERE ID > ?", 5)
+//
+// Statements are routed to the DDL, DML or DQL endpoint by the SQL analyzer, placeholders (?, $1 or :name)
+// are bound as escaped literals and biscuits are attached by the underlying sqlcore.Client.
+// Transactions are not supported and return ErrTransactionsUnsupported
+package sqldriver
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/storage"
+)
+
+// Origin app sent when the DSN has no origin
+const DefaultOriginApp = "sxt-database-sql"
+
+var (
+	// Returned by Begin and BeginTx, the gateway runs every statement on its own
+	ErrTransactionsUnsupported = errors.New("sxt: transactions are not supported")
+
+	// Returned by Result.LastInsertId, SxT has no auto generated ids
+	ErrLastInsertIdUnsupported = errors.New("sxt: LastInsertId is not supported")
+
+	// Returned by Result.RowsAffected when the gateway didn't report a count
+	ErrRowsAffectedUnknown = errors.New("sxt: rows affected not reported")
+)
+
+func init() {
+	sql.Register("sxt", &Driver{})
+}
+
+type Driver struct{}
+
+// Open a connection for a DSN, see OpenConnector
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return connector.Connect(context.Background())
+}
+
+// Parse a DSN of URL query parameters:
+//
+//	profile=<user id>   access token and private key of a session stored by storage.FileWriteSession
+//	keyfile=<path>      file holding a standard base64 private key, with the access token from the accessToken environment variable
+//	registry=true       use the biscuits registered for the profile instead of minting them with its key
+//	origin=<app>        origin app sent to the gateway, DefaultOriginApp when empty
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	client, err := clientFromDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Connector{client: client, driver: d}, nil
+}
+
+// Opens connections backed by a sqlcore.Client
+type Connector struct {
+	client *sqlcore.Client
+	driver driver.Driver
+}
+
+// Connector for a configured client, to be used with sql.OpenDB
+func NewConnector(client *sqlcore.Client) *Connector {
+	return &Connector{client: client, driver: &Driver{}}
+}
+
+// Connections are cheap: they share the client and its HTTP connection pool
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{client: c.client}, nil
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
+
+func clientFromDSN(dsn string) (*sqlcore.Client, error) {
+	values, err := url.ParseQuery(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sxt: invalid DSN: %w", err)
+	}
+
+	for name := range values {
+		switch name {
+		case "profile", "keyfile", "registry", "origin":
+		default:
+			return nil, fmt.Errorf("sxt: unknown DSN parameter %q", name)
+		}
+	}
+
+	origin := values.Get("origin")
+	if origin == "" {
+		origin = DefaultOriginApp
+	}
+	client := sqlcore.NewClient(origin)
+
+	profile, keyfile := values.Get("profile"), values.Get("keyfile")
+	switch {
+	case profile != "" && keyfile != "":
+		return nil, errors.New("sxt: DSN has both profile and keyfile")
+
+	case profile != "":
+		session, ok := storage.FileReadSession(profile)
+		if !ok {
+			return nil, fmt.Errorf("sxt: no session stored for profile %s", profile)
+		}
+		client.AccessToken = session.AccessToken
+
+		if values.Get("registry") == "true" {
+			registry, err := sqlcore.NewBiscuitRegistry(sqlcore.FileBiscuitStore{UserId: profile})
+			if err != nil {
+				return nil, err
+			}
+			client.Biscuits = registry
+		} else {
+			client.MintKey = session.PrivateKey
+		}
+
+	case keyfile != "":
+		privateKey, err := readKeyFile(keyfile)
+		if err != nil {
+			return nil, err
+		}
+		client.MintKey = privateKey
+
+	default:
+		return nil, errors.New("sxt: DSN needs a profile or keyfile")
+	}
+
+	return client, nil
+}
+
+// Key files hold a standard base64 32 byte seed or 64 byte ed25519 key
+func readKeyFile(filename string) (ed25519.PrivateKey, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("sxt: %s doesn't hold a base64 std encoded private key", filename)
+	}
+
+	switch len(keyBytes) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(keyBytes), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(keyBytes), nil
+	}
+
+	return nil, fmt.Errorf("sxt: private key has %d bytes, expected 32 or 64", len(keyBytes))
+}
@@ -0,0 +1,152 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+// Answers queries with fixed rows and DML with an update count
+type gatewayStub struct {
+	statements []string
+}
+
+func (g *gatewayStub) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body struct {
+		SqlText string `json:"sqlText"`
+	}
+	json.NewDecoder(request.Body).Decode(&body)
+	g.statements = append(g.statements, body.SqlText)
+
+	response := `[{"UPDATED": 1}]`
+	if strings.HasSuffix(request.URL.Path, "/dql") {
+		response = `[{"ID": 1, "TEST": "a", "PRICE": 1.25, "CREATED": "2023-03-01T10:00:00Z"}, {"ID": 2, "TEST": null, "PRICE": 3, "CREATED": "2023-03-02T10:00:00Z"}]`
+	}
+
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response)), Header: http.Header{}}, nil
+}
+
+func testDB(t *testing.T) (*sql.DB, *gatewayStub) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+
+	gateway := &gatewayStub{}
+	client := sqlcore.NewClient("test")
+	client.HTTPClient = &http.Client{Transport: gateway}
+
+	return sql.OpenDB(NewConnector(client)), gateway
+}
+
+func TestQuery(t *testing.T) {
+	db, gateway := testDB(t)
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), "SELECT * FROM ETH.T WHERE TEST = ? AND ID > ?", "it's", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	if gateway.statements[0] != "SELECT * FROM ETH.T WHERE TEST = 'it''s' AND ID > 0" {
+		t.Errorf("unexpected statement %s", gateway.statements[0])
+	}
+
+	columns, _ := rows.Columns()
+	if strings.Join(columns, ",") != "ID,TEST,PRICE,CREATED" {
+		t.Errorf("unexpected columns %v", columns)
+	}
+
+	types, _ := rows.ColumnTypes()
+	if types[0].DatabaseTypeName() != "INTEGER" {
+		t.Errorf("unexpected column type %s", types[0].DatabaseTypeName())
+	}
+
+	count := 0
+	for rows.Next() {
+		var id int64
+		var test sql.NullString
+		var price float64
+		var created time.Time
+		if err := rows.Scan(&id, &test, &price, &created); err != nil {
+			t.Fatal(err)
+		}
+
+		if id == 1 && (test.String != "a" || price != 1.25 || created.Day() != 1) {
+			t.Errorf("unexpected row %d %v %v %v", id, test, price, created)
+		}
+		if id == 2 && test.Valid {
+			t.Errorf("expected NULL, got %v", test)
+		}
+		count++
+	}
+
+	if rows.Err() != nil || count != 2 {
+		t.Errorf("expected 2 rows, got %d: %v", count, rows.Err())
+	}
+}
+
+func TestColumnScanTypes(t *testing.T) {
+	db, _ := testDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT * FROM ETH.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	// Generic scanners allocate the scan type of each column
+	types, _ := rows.ColumnTypes()
+	for rows.Next() {
+		dest := []interface{}{reflect.New(types[0].ScanType()).Interface(), &sql.NullString{}, reflect.New(types[2].ScanType()).Interface(), reflect.New(types[3].ScanType()).Interface()}
+		if err := rows.Scan(dest...); err != nil {
+			t.Fatal(err)
+		}
+
+		if price := *dest[2].(*string); price != "1.25" && price != "3" {
+			t.Errorf("unexpected price %s", price)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	db, gateway := testDB(t)
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE ETH.T SET TEST = :test WHERE ID = :id", sql.Named("test", "b"), sql.Named("id", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected != 1 {
+		t.Errorf("expected 1 row affected, got %d: %v", affected, err)
+	}
+
+	if _, err := result.LastInsertId(); !errors.Is(err, ErrLastInsertIdUnsupported) {
+		t.Errorf("unexpected LastInsertId error %v", err)
+	}
+
+	if gateway.statements[0] != "UPDATE ETH.T SET TEST = 'b' WHERE ID = 1" {
+		t.Errorf("unexpected statement %s", gateway.statements[0])
+	}
+
+	if _, err := db.Begin(); !errors.Is(err, ErrTransactionsUnsupported) {
+		t.Errorf("expected transactions to be unsupported, got %v", err)
+	}
+}
+
+func TestOpenConnector(t *testing.T) {
+	for _, dsn := range []string{"", "profile=a&keyfile=b", "unknown=1", "keyfile=/does/not/exist"} {
+		if _, err := (&Driver{}).OpenConnector(dsn); err == nil {
+			t.Errorf("%q: expected an error", dsn)
+		}
+	}
+}
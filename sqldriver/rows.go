@@ -0,0 +1,130 @@
+package sqldriver
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+// Rows of a streamed query. The first row is read ahead, as database/sql asks for the columns before any row
+type rows struct {
+	stream  *sqlcore.RowStream
+	columns []sqlcore.Column
+	ahead   bool
+}
+
+func newRows(stream *sqlcore.RowStream) (*rows, error) {
+	r := &rows{stream: stream}
+
+	r.ahead = stream.Next()
+	if err := stream.Err(); err != nil {
+		stream.Close()
+		return nil, err
+	}
+
+	r.columns = append(r.columns, stream.Columns()...)
+	return r, nil
+}
+
+func (r *rows) Columns() []string {
+	names := make([]string, len(r.columns))
+	for idx, column := range r.columns {
+		names[idx] = column.Name
+	}
+
+	return names
+}
+
+// Closing before the last row drops the connection, leaving the rest of the result undownloaded
+func (r *rows) Close() error {
+	if r.stream == nil {
+		return nil
+	}
+
+	return r.stream.Close()
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.stream == nil {
+		return io.EOF
+	}
+
+	if r.ahead {
+		r.ahead = false
+	} else if !r.stream.Next() {
+		if err := r.stream.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	row := r.stream.Row()
+	values := row.Values()
+	for idx := range dest {
+		dest[idx] = nil
+		if idx < len(values) && idx < len(r.columns) {
+			dest[idx] = driverValue(row, r.columns[idx], values[idx])
+		}
+	}
+
+	return nil
+}
+
+// Convert a decoded value to a driver.Value. Decimals stay strings so no precision is lost,
+// database/sql converts them when scanning into numbers
+func driverValue(row sqlcore.Row, column sqlcore.Column, value interface{}) driver.Value {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+		return v.String()
+	case json.RawMessage:
+		return []byte(v)
+	case string:
+		if column.Type == sqlcore.TypeTimestamp {
+			if parsed, err := row.Time(column.Name); err == nil {
+				return parsed
+			}
+		}
+		return v
+	}
+
+	return value
+}
+
+// Column types are inferred from the first row
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return string(r.columns[index].Type)
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	switch r.columns[index].Type {
+	case sqlcore.TypeInteger:
+		return reflect.TypeOf(int64(0))
+	case sqlcore.TypeDecimal:
+		// Decimals are delivered as strings, see driverValue
+		return reflect.TypeOf("")
+	case sqlcore.TypeBoolean:
+		return reflect.TypeOf(false)
+	case sqlcore.TypeTimestamp:
+		return reflect.TypeOf(time.Time{})
+	case sqlcore.TypeJSON:
+		return reflect.TypeOf([]byte(nil))
+	case sqlcore.TypeVarchar:
+		return reflect.TypeOf("")
+	}
+
+	return reflect.TypeOf((*interface{})(nil)).Elem()
+}
+
+// Nullability isn't known from a response
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return false, false
+}
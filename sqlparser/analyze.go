@@ -0,0 +1,472 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/authorization"
+)
+
+// Statement kinds, matching the gateway sql endpoints
+const (
+	KindDDL = "DDL"
+	KindDML = "DML"
+	KindDQL = "DQL"
+)
+
+// A table, view or schema referenced by a statement.
+// Unquoted names are upper cased the way SxT stores them
+type TableRef struct {
+	Schema string
+	Name   string
+	Pos    Position
+}
+
+func (t TableRef) String() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+
+	return t.Schema + "." + t.Name
+}
+
+// Result of analyzing a single SQL statement
+type Statement struct {
+	Kind string
+
+	// Biscuit operation of the statement, e.g. dql_select or dml_insert
+	Operation string
+
+	// Object type for DDL statements: SCHEMA, TABLE, VIEW or INDEX
+	Object string
+
+	// Table the statement writes or defines. Nil for queries
+	Target *TableRef
+
+	// Tables only read by the statement, CTE names excluded
+	Sources []TableRef
+
+	Tokens []Token
+}
+
+// Every resource referenced by the statement, target first, without duplicates
+func (s *Statement) Resources() (resources []string) {
+	seen := map[string]bool{}
+	add := func(table TableRef) {
+		name := table.String()
+		if !seen[name] {
+			seen[name] = true
+			resources = append(resources, name)
+		}
+	}
+
+	if s.Target != nil {
+		add(*s.Target)
+	}
+
+	for _, table := range s.Sources {
+		add(table)
+	}
+
+	return resources
+}
+
+// Least privilege capabilities for the statement:
+// its operation on the target and dql_select on the tables it reads
+func (s *Statement) Capabilities() (capabilities []authorization.SxTBiscuitStruct) {
+	seen := map[authorization.SxTBiscuitStruct]bool{}
+	add := func(operation string, table TableRef) {
+		capability := authorization.SxTBiscuitStruct{Operation: operation, Resource: strings.ToLower(table.String())}
+		if !seen[capability] {
+			seen[capability] = true
+			capabilities = append(capabilities, capability)
+		}
+	}
+
+	if s.Target != nil {
+		add(s.Operation, *s.Target)
+	}
+
+	for _, table := range s.Sources {
+		add("dql_select", table)
+	}
+
+	return capabilities
+}
+
+// Find the operation and the tables of a single SQL statement.
+// Statements that cannot be analyzed return an error, a *SyntaxError when the position is known
+func Analyze(sqlText string) (statement *Statement, err error) {
+	tokens, err := Tokenize(sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop trailing semicolons, reject anything after one
+	end := len(tokens) - 1
+	for end > 0 && tokens[end-1].Text == ";" && tokens[end-1].Type == Punct {
+		end--
+	}
+	tokens = append(tokens[:end:end], tokens[len(tokens)-1])
+
+	for _, token := range tokens {
+		if token.Type == Punct && token.Text == ";" {
+			return nil, &SyntaxError{Pos: token.Pos, Message: "only a single statement can be analyzed"}
+		}
+	}
+
+	if tokens[0].Type == EOF {
+		return nil, &SyntaxError{Pos: tokens[0].Pos, Message: "empty statement"}
+	}
+
+	a := &analyzer{tokens: tokens, ctes: map[string]bool{}}
+	statement = &Statement{Tokens: tokens}
+	if err := a.statement(statement); err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+type analyzer struct {
+	tokens    []Token
+	pos       int
+	ctes      map[string]bool
+	cteBodies [][2]int
+}
+
+func (a *analyzer) peek() Token {
+	return a.tokens[a.pos]
+}
+
+func (a *analyzer) next() Token {
+	token := a.tokens[a.pos]
+	if token.Type != EOF {
+		a.pos++
+	}
+
+	return token
+}
+
+func (a *analyzer) accept(keywords ...string) bool {
+	for i, keyword := range keywords {
+		if a.pos+i >= len(a.tokens) || !a.tokens[a.pos+i].Is(keyword) {
+			return false
+		}
+	}
+
+	a.pos += len(keywords)
+	return true
+}
+
+func (a *analyzer) expect(keyword string) error {
+	if !a.accept(keyword) {
+		return a.errorf("expected %s", keyword)
+	}
+
+	return nil
+}
+
+func (a *analyzer) errorf(format string, args ...interface{}) error {
+	token := a.peek()
+	message := fmt.Sprintf(format, args...)
+	if token.Type == EOF {
+		message += " but the statement ended"
+	} else {
+		message += fmt.Sprintf(", found %q", token.Text)
+	}
+
+	return &SyntaxError{Pos: token.Pos, Message: message}
+}
+
+func (a *analyzer) statement(s *Statement) error {
+	if a.peek().Is("WITH") {
+		if err := a.commonTableExpressions(); err != nil {
+			return err
+		}
+	}
+
+	first := a.peek()
+	switch first.Keyword() {
+	case "SELECT":
+		s.Kind, s.Operation = KindDQL, "dql_select"
+
+	case "INSERT":
+		a.next()
+		s.Kind, s.Operation = KindDML, "dml_insert"
+		if err := a.expect("INTO"); err != nil {
+			return err
+		}
+		if err := a.target(s); err != nil {
+			return err
+		}
+
+	case "UPDATE":
+		a.next()
+		s.Kind, s.Operation = KindDML, "dml_update"
+		if err := a.target(s); err != nil {
+			return err
+		}
+
+	case "DELETE":
+		a.next()
+		s.Kind, s.Operation = KindDML, "dml_delete"
+		if err := a.expect("FROM"); err != nil {
+			return err
+		}
+		if err := a.target(s); err != nil {
+			return err
+		}
+
+	case "MERGE":
+		a.next()
+		s.Kind, s.Operation = KindDML, "dml_merge"
+		if err := a.expect("INTO"); err != nil {
+			return err
+		}
+		if err := a.target(s); err != nil {
+			return err
+		}
+
+	case "CREATE", "ALTER", "DROP":
+		a.next()
+		s.Kind, s.Operation = KindDDL, "ddl_"+strings.ToLower(first.Keyword())
+		if err := a.definition(s); err != nil {
+			return err
+		}
+
+	default:
+		return &SyntaxError{Pos: first.Pos, Message: fmt.Sprintf("unsupported statement %q", first.Text)}
+	}
+
+	return a.sources(s)
+}
+
+// Skip `WITH name [(columns)] AS (...)[, ...]` and remember the names so they are not taken for tables
+func (a *analyzer) commonTableExpressions() error {
+	a.next()
+	a.accept("RECURSIVE")
+
+	for {
+		if !isName(a.peek()) {
+			return a.errorf("expected common table expression name")
+		}
+		a.ctes[normalizeName(a.next())] = true
+
+		if a.peek().Text == "(" {
+			if err := a.skipParentheses(); err != nil {
+				return err
+			}
+		}
+
+		if err := a.expect("AS"); err != nil {
+			return err
+		}
+
+		if a.peek().Text != "(" {
+			return a.errorf("expected (")
+		}
+
+		// Tables read inside the CTE body are found by the sources scan later
+		start := a.pos
+		if err := a.skipParentheses(); err != nil {
+			return err
+		}
+		a.cteBodies = append(a.cteBodies, [2]int{start, a.pos})
+
+		if a.peek().Text != "," {
+			return nil
+		}
+		a.next()
+	}
+}
+
+// Parse what follows CREATE, ALTER or DROP
+func (a *analyzer) definition(s *Statement) error {
+	a.accept("OR", "REPLACE")
+	a.accept("UNIQUE")
+
+	switch a.peek().Keyword() {
+	case "SCHEMA", "TABLE", "VIEW", "INDEX":
+		s.Object = a.next().Keyword()
+	default:
+		return a.errorf("expected SCHEMA, TABLE, VIEW or INDEX")
+	}
+
+	a.accept("IF", "NOT", "EXISTS")
+	a.accept("IF", "EXISTS")
+
+	if s.Object == "SCHEMA" {
+		if !isName(a.peek()) {
+			return a.errorf("expected schema name")
+		}
+		name := a.next()
+		s.Target = &TableRef{Name: normalizeName(name), Pos: name.Pos}
+		return nil
+	}
+
+	if s.Object == "INDEX" && s.Operation == "ddl_create" {
+		// CREATE INDEX name ON table: the biscuit resource is the table
+		if !a.peek().Is("ON") {
+			a.next()
+		}
+		if err := a.expect("ON"); err != nil {
+			return err
+		}
+	}
+
+	return a.target(s)
+}
+
+func (a *analyzer) target(s *Statement) error {
+	table, err := a.tableName()
+	if err != nil {
+		return err
+	}
+
+	s.Target = &table
+	return nil
+}
+
+// Parse `[schema.]name`
+func (a *analyzer) tableName() (table TableRef, err error) {
+	first := a.peek()
+	if !isName(first) || reservedWords[first.Keyword()] {
+		return TableRef{}, a.errorf("expected table name")
+	}
+
+	parts := []string{normalizeName(a.next())}
+	for a.peek().Text == "." && a.peek().Type == Punct {
+		a.next()
+		if !isName(a.peek()) {
+			return TableRef{}, a.errorf("expected name after .")
+		}
+		parts = append(parts, normalizeName(a.next()))
+	}
+
+	table = TableRef{Name: parts[len(parts)-1], Pos: first.Pos}
+	if len(parts) > 1 {
+		table.Schema = parts[len(parts)-2]
+	}
+
+	return table, nil
+}
+
+// Scan the rest of the statement for tables read after FROM, JOIN and USING
+func (a *analyzer) sources(s *Statement) error {
+	// Scan CTE bodies as well as the main statement
+	ranges := append(a.cteBodies, [2]int{a.pos, len(a.tokens) - 1})
+
+	for _, r := range ranges {
+		// true for parentheses of function calls, where FROM is part of the call, e.g. EXTRACT(YEAR FROM x)
+		var functionCall []bool
+
+		for a.pos = r[0]; a.pos < r[1]; {
+			token := a.next()
+
+			switch {
+			case token.Type == Punct && token.Text == "(":
+				previous := Token{}
+				if a.pos >= 2 {
+					previous = a.tokens[a.pos-2]
+				}
+				functionCall = append(functionCall, isName(previous) && !reservedWords[previous.Keyword()])
+
+			case token.Type == Punct && token.Text == ")":
+				if len(functionCall) > 0 {
+					functionCall = functionCall[:len(functionCall)-1]
+				}
+
+			case token.Is("FROM") || token.Is("JOIN") || token.Is("USING"):
+				if len(functionCall) > 0 && functionCall[len(functionCall)-1] {
+					continue
+				}
+				if err := a.tableList(s, token.Is("FROM")); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Read tables after FROM, JOIN or USING. FROM may list several tables separated by commas
+func (a *analyzer) tableList(s *Statement, list bool) error {
+	for {
+		// Subqueries and USING (columns) are scanned by the caller
+		if a.peek().Text == "(" || !isName(a.peek()) || reservedWords[a.peek().Keyword()] {
+			return nil
+		}
+
+		table, err := a.tableName()
+		if err != nil {
+			return err
+		}
+
+		if table.Schema != "" || !a.ctes[table.Name] {
+			s.Sources = append(s.Sources, table)
+		}
+
+		// Optional alias
+		if a.accept("AS") || (isName(a.peek()) && !reservedWords[a.peek().Keyword()]) {
+			a.next()
+		}
+
+		if !list || a.peek().Text != "," {
+			return nil
+		}
+		a.next()
+	}
+}
+
+func (a *analyzer) skipParentheses() error {
+	start := a.peek()
+	depth := 0
+	for {
+		token := a.next()
+		switch {
+		case token.Type == EOF:
+			return &SyntaxError{Pos: start.Pos, Message: "unbalanced parentheses"}
+		case token.Type == Punct && token.Text == "(":
+			depth++
+		case token.Type == Punct && token.Text == ")":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func isName(token Token) bool {
+	return token.Type == Ident || token.Type == QuotedIdent
+}
+
+// Unquoted identifiers are case insensitive and stored upper cased
+func normalizeName(token Token) string {
+	if token.Type == QuotedIdent {
+		return token.Value
+	}
+
+	return strings.ToUpper(token.Text)
+}
+
+// Reports if an upper cased word can't be a table name or alias
+func IsReserved(keyword string) bool {
+	return reservedWords[keyword]
+}
+
+// Words that cannot be table names or aliases
+var reservedWords = map[string]bool{
+	"ALL": true, "ALTER": true, "AND": true, "ANY": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true,
+	"CASE": true, "CREATE": true, "CROSS": true, "DEFAULT": true, "DELETE": true, "DESC": true, "DISTINCT": true,
+	"DROP": true, "ELSE": true, "END": true, "EXCEPT": true, "EXISTS": true, "FALSE": true, "FETCH": true,
+	"FOR": true, "FOREIGN": true, "FROM": true, "FULL": true, "GROUP": true, "HAVING": true, "IN": true,
+	"INNER": true, "INSERT": true, "INTERSECT": true, "INTO": true, "IS": true, "JOIN": true, "KEY": true,
+	"LATERAL": true, "LEFT": true, "LIKE": true, "LIMIT": true, "MATCHED": true, "MERGE": true, "NATURAL": true,
+	"NOT": true, "NULL": true, "OFFSET": true, "ON": true, "OR": true, "ORDER": true, "OUTER": true,
+	"PRIMARY": true, "REFERENCES": true, "RIGHT": true, "SELECT": true, "SET": true, "SOME": true, "TABLE": true,
+	"THEN": true, "TRUE": true, "UNION": true, "UNIQUE": true, "UPDATE": true, "USING": true, "VALUES": true,
+	"WHEN": true, "WHERE": true, "WITH": true,
+}
@@ -0,0 +1,76 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		sqlText   string
+		operation string
+		resources []string
+	}{
+		{"select * from ETH.BLOCKS", "dql_select", []string{"ETH.BLOCKS"}},
+		{"SELECT a.ID FROM eth.t1 a, ETH.T3 JOIN ETH.T2 AS b ON a.ID = b.ID", "dql_select", []string{"ETH.T1", "ETH.T3", "ETH.T2"}},
+		{"SELECT EXTRACT(YEAR FROM TS) FROM ETH.T1 WHERE ID IN (SELECT ID FROM ETH.T2)", "dql_select", []string{"ETH.T1", "ETH.T2"}},
+		{"WITH RECENT AS (SELECT * FROM ETH.T1) SELECT * FROM RECENT;", "dql_select", []string{"ETH.T1"}},
+		{"insert into ETH.TESTTABLE106 values(5, 'x5')", "dml_insert", []string{"ETH.TESTTABLE106"}},
+		{"INSERT INTO ETH.T1 (ID) SELECT ID FROM ETH.T2", "dml_insert", []string{"ETH.T1", "ETH.T2"}},
+		{"UPDATE ETH.T1 SET TEST = 'a;b' WHERE ID = 5", "dml_update", []string{"ETH.T1"}},
+		{"DELETE FROM ETH.T1 WHERE ID = 5", "dml_delete", []string{"ETH.T1"}},
+		{"MERGE INTO ETH.T1 T USING ETH.T2 S ON T.ID = S.ID WHEN MATCHED THEN UPDATE SET TEST = S.TEST", "dml_merge", []string{"ETH.T1", "ETH.T2"}},
+		{"CREATE TABLE ETH.T1 (ID INT PRIMARY KEY, TEST VARCHAR)", "ddl_create", []string{"ETH.T1"}},
+		{"CREATE SCHEMA ETH", "ddl_create", []string{"ETH"}},
+		{"ALTER TABLE ETH.T1 ADD TEST2 VARCHAR", "ddl_alter", []string{"ETH.T1"}},
+		{"DROP TABLE IF EXISTS \"ETH\".\"T1\"", "ddl_drop", []string{"ETH.T1"}},
+	}
+
+	for _, test := range tests {
+		statement, err := Analyze(test.sqlText)
+		if err != nil {
+			t.Errorf("%s: %v", test.sqlText, err)
+			continue
+		}
+
+		if statement.Operation != test.operation {
+			t.Errorf("%s: expected operation %s, got %s", test.sqlText, test.operation, statement.Operation)
+		}
+
+		if !reflect.DeepEqual(statement.Resources(), test.resources) {
+			t.Errorf("%s: expected resources %v, got %v", test.sqlText, test.resources, statement.Resources())
+		}
+	}
+}
+
+func TestAnalyzeCapabilities(t *testing.T) {
+	statement, err := Analyze("INSERT INTO ETH.T1 SELECT * FROM ETH.T2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capabilities := statement.Capabilities()
+	if len(capabilities) != 2 || capabilities[0].Operation != "dml_insert" || capabilities[1].Operation != "dql_select" || capabilities[1].Resource != "eth.t2" {
+		t.Errorf("unexpected capabilities %v", capabilities)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	for _, sqlText := range []string{
+		"",
+		"GRANT SELECT ON ETH.T1 TO BOB",
+		"SELECT 1; SELECT 2",
+		"INSERT ETH.T1 VALUES (1)",
+		"SELECT * FROM ETH.T1 WHERE TEST = 'open",
+	} {
+		if _, err := Analyze(sqlText); err == nil {
+			t.Errorf("%q: expected an error", sqlText)
+		}
+	}
+
+	_, err := Analyze("SELECT *\nFROM ETH.T1 WHERE $")
+	syntaxError, ok := err.(*SyntaxError)
+	if !ok || syntaxError.Pos.Line != 2 || syntaxError.Pos.Column != 19 {
+		t.Errorf("expected a syntax error at 2:19, got %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenType int
+
+const (
+	EOF TokenType = iota
+	Ident
+	QuotedIdent
+	String
+	Number
+	Punct
+	Param
+)
+
+// Position of a token in the SQL text. Line and Column start at 1
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+type Token struct {
+	Type  TokenType
+	Text  string
+	Value string
+	Pos   Position
+}
+
+// Upper cased text for unquoted identifiers, used for keyword matching
+func (t Token) Keyword() string {
+	if t.Type != Ident {
+		return ""
+	}
+
+	return strings.ToUpper(t.Text)
+}
+
+func (t Token) Is(keyword string) bool {
+	return t.Keyword() == keyword
+}
+
+// Error with the position it happened at
+type SyntaxError struct {
+	Pos     Position
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Split SQL text into tokens. Comments and whitespace are dropped.
+// Value holds the unescaped content of strings and quoted identifiers
+func Tokenize(sqlText string) (tokens []Token, err error) {
+	l := lexer{input: sqlText, line: 1, column: 1}
+
+	for {
+		token, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+		if token.Type == EOF {
+			return tokens, nil
+		}
+	}
+}
+
+type lexer struct {
+	input  string
+	offset int
+	line   int
+	column int
+}
+
+func (l *lexer) position() Position {
+	return Position{Offset: l.offset, Line: l.line, Column: l.column}
+}
+
+func (l *lexer) peek(ahead int) rune {
+	offset := l.offset
+	for i := 0; i < ahead; i++ {
+		if offset >= len(l.input) {
+			return 0
+		}
+		_, size := utf8.DecodeRuneInString(l.input[offset:])
+		offset += size
+	}
+
+	if offset >= len(l.input) {
+		return 0
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.input[offset:])
+	return r
+}
+
+func (l *lexer) advance() rune {
+	r, size := utf8.DecodeRuneInString(l.input[l.offset:])
+	l.offset += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+
+	return r
+}
+
+func (l *lexer) skipSpaceAndComments() error {
+	for l.offset < len(l.input) {
+		r := l.peek(0)
+		switch {
+		case unicode.IsSpace(r):
+			l.advance()
+		case r == '-' && l.peek(1) == '-':
+			for l.offset < len(l.input) && l.peek(0) != '\n' {
+				l.advance()
+			}
+		case r == '/' && l.peek(1) == '*':
+			start := l.position()
+			l.advance()
+			l.advance()
+			for {
+				if l.offset >= len(l.input) {
+					return &SyntaxError{Pos: start, Message: "unterminated comment"}
+				}
+				if l.peek(0) == '*' && l.peek(1) == '/' {
+					l.advance()
+					l.advance()
+					break
+				}
+				l.advance()
+			}
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (l *lexer) next() (token Token, err error) {
+	if err := l.skipSpaceAndComments(); err != nil {
+		return Token{}, err
+	}
+
+	start := l.position()
+	if l.offset >= len(l.input) {
+		return Token{Type: EOF, Pos: start}, nil
+	}
+
+	r := l.peek(0)
+	switch {
+	case r == '\'':
+		value, err := l.quoted('\'')
+		if err != nil {
+			return Token{}, err
+		}
+		return Token{Type: String, Text: l.input[start.Offset:l.offset], Value: value, Pos: start}, nil
+
+	case r == '"':
+		value, err := l.quoted('"')
+		if err != nil {
+			return Token{}, err
+		}
+		return Token{Type: QuotedIdent, Text: l.input[start.Offset:l.offset], Value: value, Pos: start}, nil
+
+	case isIdentStart(r):
+		for l.offset < len(l.input) && isIdentPart(l.peek(0)) {
+			l.advance()
+		}
+		text := l.input[start.Offset:l.offset]
+		return Token{Type: Ident, Text: text, Value: text, Pos: start}, nil
+
+	case unicode.IsDigit(r) || (r == '.' && unicode.IsDigit(l.peek(1))):
+		l.number()
+		text := l.input[start.Offset:l.offset]
+		return Token{Type: Number, Text: text, Value: text, Pos: start}, nil
+
+	case r == '?':
+		l.advance()
+		return Token{Type: Param, Text: "?", Value: "", Pos: start}, nil
+
+	case r == '$' && unicode.IsDigit(l.peek(1)):
+		l.advance()
+		for l.offset < len(l.input) && unicode.IsDigit(l.peek(0)) {
+			l.advance()
+		}
+		text := l.input[start.Offset:l.offset]
+		return Token{Type: Param, Text: text, Value: text[1:], Pos: start}, nil
+
+	case r == ':' && isIdentStart(l.peek(1)):
+		l.advance()
+		for l.offset < len(l.input) && isIdentPart(l.peek(0)) {
+			l.advance()
+		}
+		text := l.input[start.Offset:l.offset]
+		return Token{Type: Param, Text: text, Value: text[1:], Pos: start}, nil
+	}
+
+	// Two character operators first
+	for _, operator := range []string{"<=", ">=", "<>", "!=", "||", "::"} {
+		if strings.HasPrefix(l.input[l.offset:], operator) {
+			l.advance()
+			l.advance()
+			return Token{Type: Punct, Text: operator, Value: operator, Pos: start}, nil
+		}
+	}
+
+	l.advance()
+	text := l.input[start.Offset:l.offset]
+	if !strings.ContainsRune("(),.;=<>+-*/%", r) {
+		return Token{}, &SyntaxError{Pos: start, Message: fmt.Sprintf("unexpected character %q", r)}
+	}
+
+	return Token{Type: Punct, Text: text, Value: text, Pos: start}, nil
+}
+
+// Read a quoted string. A doubled quote stands for one quote
+func (l *lexer) quoted(quote rune) (value string, err error) {
+	start := l.position()
+	l.advance()
+
+	var builder strings.Builder
+	for {
+		if l.offset >= len(l.input) {
+			if quote == '"' {
+				return "", &SyntaxError{Pos: start, Message: "unterminated quoted identifier"}
+			}
+			return "", &SyntaxError{Pos: start, Message: "unterminated string"}
+		}
+
+		r := l.advance()
+		if r == quote {
+			if l.peek(0) != quote {
+				return builder.String(), nil
+			}
+			l.advance()
+		}
+		builder.WriteRune(r)
+	}
+}
+
+func (l *lexer) number() {
+	for l.offset < len(l.input) && (unicode.IsDigit(l.peek(0)) || l.peek(0) == '.') {
+		l.advance()
+	}
+
+	if r := l.peek(0); r == 'e' || r == 'E' {
+		next := l.peek(1)
+		if unicode.IsDigit(next) || ((next == '+' || next == '-') && unicode.IsDigit(l.peek(2))) {
+			l.advance()
+			l.advance()
+			for l.offset < len(l.input) && unicode.IsDigit(l.peek(0)) {
+				l.advance()
+			}
+		}
+	}
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,37 @@
+package sqlparser
+
+import "strings"
+
+// A statement of a script, without its semicolon
+type ScriptStatement struct {
+	SQL string
+	Pos Position
+}
+
+// Split a script into statements at semicolons outside strings, quoted identifiers and comments.
+// Comments before a statement and empty statements are dropped
+func Split(script string) (statements []ScriptStatement, err error) {
+	tokens, err := Tokenize(script)
+	if err != nil {
+		return nil, err
+	}
+
+	start := -1
+	for _, token := range tokens {
+		end := token.Type == EOF || (token.Type == Punct && token.Text == ";")
+		if !end {
+			if start < 0 {
+				start = token.Pos.Offset
+				statements = append(statements, ScriptStatement{Pos: token.Pos})
+			}
+			continue
+		}
+
+		if start >= 0 {
+			statements[len(statements)-1].SQL = strings.TrimSpace(script[start:token.Pos.Offset])
+			start = -1
+		}
+	}
+
+	return statements, nil
+}
@@ -0,0 +1,40 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	script := `-- create the table
+CREATE TABLE ETH.T1 (ID INT PRIMARY KEY, TEST VARCHAR);;
+INSERT INTO ETH.T1 VALUES (1, 'a;b') /* ; */;
+SELECT * FROM ETH.T1`
+
+	statements, err := Split(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sqlTexts []string
+	for _, statement := range statements {
+		sqlTexts = append(sqlTexts, statement.SQL)
+	}
+
+	expected := []string{
+		"CREATE TABLE ETH.T1 (ID INT PRIMARY KEY, TEST VARCHAR)",
+		"INSERT INTO ETH.T1 VALUES (1, 'a;b') /* ; */",
+		"SELECT * FROM ETH.T1",
+	}
+	if !reflect.DeepEqual(sqlTexts, expected) {
+		t.Errorf("unexpected statements %q", sqlTexts)
+	}
+
+	if statements[1].Pos.Line != 3 || statements[1].Pos.Column != 1 {
+		t.Errorf("unexpected position %s", statements[1].Pos)
+	}
+
+	if _, err := Split("SELECT 'unterminated"); err == nil {
+		t.Error("expected an unterminated string error")
+	}
+}
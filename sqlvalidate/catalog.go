@@ -0,0 +1,85 @@
+package sqlvalidate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/helpers"
+)
+
+// Names statements are checked against. Names are compared the way SxT stores them:
+// upper case, unless they were created quoted
+type Catalog interface {
+	Schemas() ([]string, error)
+
+	// Tables and views of a schema
+	Tables(schema string) ([]string, error)
+
+	// Columns of a table or view, none when they are unknown
+	Columns(schema, table string) ([]string, error)
+}
+
+// Catalog read from the discovery APIs. Turn on discovery.UseCache to keep lookups,
+// the cache is invalidated by DDL run through sqlcore so new tables are seen right away
+type DiscoveryCatalog struct{}
+
+func (DiscoveryCatalog) Schemas() (names []string, err error) {
+	schemas, errMsg, status := discovery.ListSchemas(discovery.ScopeAll, "")
+	if !status {
+		return nil, errors.New("unable to discover schemas: " + errMsg)
+	}
+
+	for _, schema := range schemas {
+		names = append(names, schema.Schema)
+	}
+
+	return names, nil
+}
+
+func (DiscoveryCatalog) Tables(schema string) (names []string, err error) {
+	// The discovery APIs only take upper case schema names
+	if _, ok := helpers.CheckUpperCase(schema); !ok {
+		return nil, nil
+	}
+
+	tables, errMsg, status := discovery.ListTables(schema, discovery.ScopeAll, "")
+	if !status {
+		return nil, fmt.Errorf("unable to discover the tables of %s: %s", schema, errMsg)
+	}
+
+	for _, table := range tables {
+		names = append(names, table.Table)
+	}
+
+	views, errMsg, status := discovery.ListViews("", discovery.AllViews)
+	if !status {
+		return nil, fmt.Errorf("unable to discover the views of %s: %s", schema, errMsg)
+	}
+
+	for _, view := range views {
+		if viewSchema, name, ok := strings.Cut(view.Name, "."); ok && strings.EqualFold(viewSchema, schema) {
+			names = append(names, strings.ToUpper(name))
+		}
+	}
+
+	return names, nil
+}
+
+func (DiscoveryCatalog) Columns(schema, table string) (names []string, err error) {
+	if _, ok := helpers.CheckUpperCase(table); !ok {
+		return nil, nil
+	}
+
+	columns, errMsg, status := discovery.ListColumns(schema, table)
+	if !status {
+		return nil, fmt.Errorf("unable to discover the columns of %s.%s: %s", schema, table, errMsg)
+	}
+
+	for _, column := range columns {
+		names = append(names, column.Column)
+	}
+
+	return names, nil
+}
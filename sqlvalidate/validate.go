@@ -0,0 +1,831 @@
+// Package sqlvalidate checks SxT SQL locally, before a network round trip: syntax, the schemas, tables
+// and columns statements reference, and dialect problems such as lower case unquoted names.
+//
+//	discovery.UseCache(discovery.NewCache(time.Minute))
+//	validator := sqlvalidate.New(sqlvalidate.DiscoveryCatalog{})
+//	problems, err := validator.Validate(script)
+//	client.Preflight = validator.Preflight
+//
+// Columns are checked in INSERT column lists, UPDATE assignments and qualified references such as B.ID.
+// Unqualified names in the SELECT list, WHERE, GROUP BY, HAVING and ORDER BY are resolved against the statement tables.
+// Statements of a script see the schemas, tables and columns created or dropped by the statements before them
+package sqlvalidate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/helpers"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlparser"
+)
+
+type Severity int
+
+const (
+	// Warnings are statements the gateway runs, but maybe not as intended
+	Warning Severity = iota
+
+	// Errors are statements the gateway rejects, or refused by the validator settings
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// A problem found in a statement, at its position in the validated text
+type Problem struct {
+	Pos      sqlparser.Position
+	Severity Severity
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Pos, p.Severity, p.Message)
+}
+
+// Returned by Preflight when a statement has errors. Problems holds warnings too
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var messages []string
+	for _, problem := range e.Problems {
+		if problem.Severity == Error {
+			messages = append(messages, problem.Pos.String()+": "+problem.Message)
+		}
+	}
+
+	return "sql validation failed: " + strings.Join(messages, "; ")
+}
+
+type Validator struct {
+	// Catalog is optional. Without it only syntax and dialect checks run
+	Catalog Catalog
+
+	// AllowUnfiltered accepts DELETE and UPDATE without WHERE, reported as errors otherwise
+	AllowUnfiltered bool
+}
+
+// Create a validator checking names against a catalog
+func New(catalog Catalog) *Validator {
+	return &Validator{Catalog: catalog}
+}
+
+// Check every statement of a script. Problems are ordered by statement and hold positions in the script.
+// The error is only set when the catalog can't be read
+func (v *Validator) Validate(script string) (problems []Problem, err error) {
+	statements, err := sqlparser.Split(script)
+	if err != nil {
+		return syntaxProblem(err)
+	}
+
+	checker := &checker{validator: v, schemas: map[string]bool{}, tables: map[string]*tableInfo{}}
+	for _, statement := range statements {
+		statementProblems, err := checker.check(statement.SQL)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, problem := range statementProblems {
+			problem.Pos = shift(problem.Pos, statement.Pos)
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems, nil
+}
+
+// Validate and fail with a *ValidationError when there are errors. Matches sqlcore.Client.Preflight
+func (v *Validator) Preflight(sqlText string) error {
+	problems, err := v.Validate(sqlText)
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range problems {
+		if problem.Severity == Error {
+			return &ValidationError{Problems: problems}
+		}
+	}
+
+	return nil
+}
+
+func syntaxProblem(err error) ([]Problem, error) {
+	var syntaxErr *sqlparser.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []Problem{{Pos: syntaxErr.Pos, Severity: Error, Message: syntaxErr.Message}}, nil
+	}
+
+	return nil, err
+}
+
+// Position in the script of a position in a statement starting at start
+func shift(pos, start sqlparser.Position) sqlparser.Position {
+	if pos.Line == 1 {
+		pos.Column += start.Column - 1
+	}
+	pos.Line += start.Line - 1
+	pos.Offset += start.Offset
+
+	return pos
+}
+
+// Columns of a table known to the checker
+type tableInfo struct {
+	// columns is nil when they are unknown, e.g. for views
+	columns map[string]bool
+}
+
+// Validation state of a script
+type checker struct {
+	validator *Validator
+
+	// Schemas and tables seen so far. false and nil entries were dropped by the script
+	schemas map[string]bool
+	tables  map[string]*tableInfo
+
+	problems []Problem
+}
+
+func (c *checker) report(pos sqlparser.Position, severity Severity, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Pos: pos, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) check(sqlText string) ([]Problem, error) {
+	c.problems = nil
+
+	statement, err := sqlparser.Analyze(sqlText)
+	if err != nil {
+		return syntaxProblem(err)
+	}
+
+	tokens := statement.Tokens
+	target := -1
+	if statement.Target != nil {
+		target = tokenIndex(tokens, statement.Target.Pos)
+		c.checkCase(tokens, target)
+	}
+	for _, source := range statement.Sources {
+		c.checkCase(tokens, tokenIndex(tokens, source.Pos))
+	}
+
+	if statement.Object == "SCHEMA" {
+		return c.problems, c.schemaStatement(statement, tokens[:target])
+	}
+
+	for _, source := range statement.Sources {
+		if _, err := c.table(source, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if statement.Target != nil {
+		if err := c.targetStatement(statement, tokens, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if statement.Kind != sqlparser.KindDDL || statement.Object == "VIEW" {
+		if err := c.checkColumns(statement, tokens, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if (statement.Operation == "dml_delete" || statement.Operation == "dml_update") && !c.validator.AllowUnfiltered && !hasTopLevel(tokens, "WHERE") {
+		c.report(statement.Target.Pos, Error, "%s without WHERE changes every row of %s", strings.ToUpper(strings.TrimPrefix(statement.Operation, "dml_")), statement.Target)
+	}
+
+	return c.problems, nil
+}
+
+// CREATE, ALTER and DROP SCHEMA
+func (c *checker) schemaStatement(statement *sqlparser.Statement, before []sqlparser.Token) error {
+	name := statement.Target.Name
+	_, seen := c.schemas[name]
+	if !seen && c.validator.Catalog == nil {
+		c.schemas[name] = statement.Operation != "ddl_drop"
+		return nil
+	}
+
+	exists, err := c.schemaExists(name)
+	if err != nil {
+		return err
+	}
+
+	switch statement.Operation {
+	case "ddl_create":
+		if exists && !hasSequence(before, "IF", "NOT", "EXISTS") {
+			c.report(statement.Target.Pos, Error, "schema %s already exists", name)
+		}
+		c.schemas[name] = true
+
+	case "ddl_drop":
+		if !exists && !hasSequence(before, "IF", "EXISTS") {
+			c.report(statement.Target.Pos, Error, "schema %s doesn't exist", name)
+		}
+		c.schemas[name] = false
+		for key := range c.tables {
+			if strings.HasPrefix(key, name+".") {
+				c.tables[key] = nil
+			}
+		}
+
+	default:
+		if !exists {
+			c.report(statement.Target.Pos, Error, "schema %s doesn't exist", name)
+		}
+	}
+
+	return nil
+}
+
+// Check the table a statement writes or defines, and record what DDL changes
+func (c *checker) targetStatement(statement *sqlparser.Statement, tokens []sqlparser.Token, target int) error {
+	ref := *statement.Target
+	key := ref.String()
+
+	switch {
+	case statement.Operation == "ddl_create" && statement.Object != "INDEX":
+		if ref.Schema == "" {
+			c.report(ref.Pos, Warning, "%s has no schema, SxT tables are named SCHEMA.TABLE", ref)
+			return nil
+		}
+
+		exists, err := c.schemaExists(ref.Schema)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			c.report(ref.Pos, Error, "schema %s doesn't exist", ref.Schema)
+			return nil
+		}
+
+		info, err := c.lookup(ref)
+		if err != nil {
+			return err
+		}
+		if info != nil && !hasSequence(tokens[:target], "IF", "NOT", "EXISTS") && !hasSequence(tokens[:target], "OR", "REPLACE") {
+			c.report(ref.Pos, Error, "%s already exists", ref)
+		}
+
+		info = &tableInfo{}
+		if statement.Object == "TABLE" {
+			info.columns = definedColumns(tokens, nameEnd(tokens, target)+1)
+		}
+		c.tables[key] = info
+
+	case statement.Operation == "ddl_drop":
+		info, err := c.table(ref, hasSequence(tokens[:target], "IF", "EXISTS"))
+		if err != nil {
+			return err
+		}
+		if info != nil {
+			c.tables[key] = nil
+		}
+
+	case statement.Operation == "ddl_alter":
+		info, err := c.table(ref, false)
+		if err != nil || info == nil {
+			return err
+		}
+		c.alterColumns(info, tokens, nameEnd(tokens, target)+1)
+
+	default:
+		if _, err := c.table(ref, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Look a referenced table up, reporting it when it doesn't exist unless missingOk.
+// Returns nil when it doesn't exist, or can't be checked
+func (c *checker) table(ref sqlparser.TableRef, missingOk bool) (*tableInfo, error) {
+	if ref.Schema == "" {
+		c.report(ref.Pos, Warning, "%s has no schema, SxT tables are named SCHEMA.TABLE", ref)
+		return nil, nil
+	}
+
+	if c.validator.Catalog == nil {
+		if info, ok := c.tables[ref.String()]; ok {
+			return info, nil
+		}
+		return nil, nil
+	}
+
+	exists, err := c.schemaExists(ref.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		c.report(ref.Pos, Error, "schema %s doesn't exist", ref.Schema)
+		return nil, nil
+	}
+
+	info, err := c.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil && !missingOk {
+		c.report(ref.Pos, Error, "%s doesn't exist", ref)
+	}
+
+	return info, nil
+}
+
+// Table seen by the script, else from the catalog. Nil when it doesn't exist
+func (c *checker) lookup(ref sqlparser.TableRef) (*tableInfo, error) {
+	key := ref.String()
+	if info, ok := c.tables[key]; ok {
+		return info, nil
+	}
+
+	if c.validator.Catalog == nil || !c.schemas[ref.Schema] {
+		return nil, nil
+	}
+
+	tables, err := c.validator.Catalog.Tables(ref.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(tables, ref.Name) {
+		return nil, nil
+	}
+
+	columns, err := c.validator.Catalog.Columns(ref.Schema, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &tableInfo{}
+	if len(columns) > 0 {
+		info.columns = map[string]bool{}
+		for _, column := range columns {
+			info.columns[column] = true
+		}
+	}
+	c.tables[key] = info
+
+	return info, nil
+}
+
+func (c *checker) schemaExists(name string) (bool, error) {
+	if exists, ok := c.schemas[name]; ok {
+		return exists, nil
+	}
+
+	if c.validator.Catalog == nil {
+		return true, nil
+	}
+
+	schemas, err := c.validator.Catalog.Schemas()
+	if err != nil {
+		return false, err
+	}
+
+	c.schemas[name] = contains(schemas, name)
+	return c.schemas[name], nil
+}
+
+// ADD [COLUMN] name and DROP [COLUMN] name. Other changes make the columns unknown
+func (c *checker) alterColumns(info *tableInfo, tokens []sqlparser.Token, start int) {
+	action := tokens[start]
+	if info.columns == nil {
+		return
+	}
+
+	switch {
+	case action.Is("ADD") || action.Is("DROP"):
+		idx := start + 1
+		if tokens[idx].Is("COLUMN") {
+			idx++
+		}
+		if !isName(tokens[idx]) || sqlparser.IsReserved(tokens[idx].Keyword()) {
+			info.columns = nil
+			return
+		}
+
+		column := normalize(tokens[idx])
+		switch {
+		case action.Is("ADD") && info.columns[column]:
+			c.report(tokens[idx].Pos, Error, "column %s already exists", column)
+		case action.Is("DROP") && !info.columns[column]:
+			c.report(tokens[idx].Pos, Error, "column %s doesn't exist", column)
+		}
+		info.columns[column] = action.Is("ADD")
+		if !info.columns[column] {
+			delete(info.columns, column)
+		}
+
+	default:
+		info.columns = nil
+	}
+}
+
+// Check INSERT column lists, UPDATE assignments, qualified column references and unqualified ones
+func (c *checker) checkColumns(statement *sqlparser.Statement, tokens []sqlparser.Token, target int) error {
+	tables := map[string]*tableInfo{}
+	names := map[int]bool{}
+
+	refs := statement.Sources
+	if statement.Kind == sqlparser.KindDML {
+		refs = append([]sqlparser.TableRef{*statement.Target}, refs...)
+	}
+
+	for _, ref := range refs {
+		info, err := c.lookup(ref)
+		if err != nil {
+			return err
+		}
+
+		start := tokenIndex(tokens, ref.Pos)
+		end := nameEnd(tokens, start)
+		for idx := start; idx <= end; idx++ {
+			names[idx] = true
+		}
+
+		tables[ref.Name] = info
+		next := end + 1
+		if tokens[next].Is("AS") {
+			next++
+		}
+		if isName(tokens[next]) && !sqlparser.IsReserved(tokens[next].Keyword()) {
+			tables[normalize(tokens[next])] = info
+			names[next] = true
+		}
+	}
+
+	for idx := 1; idx+2 < len(tokens); idx++ {
+		if names[idx] || !isName(tokens[idx]) || !isDot(tokens[idx+1]) || !isName(tokens[idx+2]) || isDot(tokens[idx-1]) || isDot(tokens[idx+3]) {
+			continue
+		}
+
+		if info, ok := tables[normalize(tokens[idx])]; ok {
+			c.checkColumn(info, tokens[idx+2])
+		}
+	}
+
+	if err := c.checkUnqualified(statement, tokens, refs); err != nil {
+		return err
+	}
+
+	if statement.Kind != sqlparser.KindDML {
+		return nil
+	}
+
+	info := tables[statement.Target.Name]
+	idx := nameEnd(tokens, target) + 1
+
+	switch statement.Operation {
+	case "dml_insert":
+		if tokens[idx].Text != "(" {
+			return nil
+		}
+		for idx++; idx < len(tokens) && tokens[idx].Text != ")"; idx++ {
+			if isName(tokens[idx]) {
+				c.checkColumn(info, tokens[idx])
+			}
+		}
+
+	case "dml_update":
+		for ; idx < len(tokens) && !tokens[idx].Is("SET"); idx++ {
+		}
+
+		// SET column = expression, ... up to WHERE or FROM
+		for idx++; idx+1 < len(tokens); idx++ {
+			if isName(tokens[idx]) && tokens[idx+1].Text == "=" {
+				c.checkColumn(info, tokens[idx])
+			}
+
+			depth := 0
+			for ; idx < len(tokens)-1; idx++ {
+				token := tokens[idx]
+				if depth == 0 && (token.Text == "," || token.Is("WHERE") || token.Is("FROM")) {
+					break
+				}
+				if token.Type == sqlparser.Punct && token.Text == "(" {
+					depth++
+				} else if token.Type == sqlparser.Punct && token.Text == ")" {
+					depth--
+				}
+			}
+
+			if tokens[idx].Text != "," {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check unqualified column references of the SELECT list, WHERE, GROUP BY, HAVING and ORDER BY against the
+// statement tables. Subqueries and statements with CTEs are skipped, as their columns come from elsewhere
+func (c *checker) checkUnqualified(statement *sqlparser.Statement, tokens []sqlparser.Token, refs []sqlparser.TableRef) error {
+	if tokens[0].Is("WITH") || statement.Operation == "dml_insert" || statement.Operation == "dml_merge" {
+		return nil
+	}
+
+	// Tokens of subqueries, whose tables don't resolve the statement columns
+	nested := map[int]bool{}
+	for idx := 0; idx+1 < len(tokens); idx++ {
+		if tokens[idx].Text == "(" && (tokens[idx+1].Is("SELECT") || tokens[idx+1].Is("WITH")) {
+			end := closingParen(tokens, idx)
+			for inner := idx; inner <= end; inner++ {
+				nested[inner] = true
+			}
+			idx = end
+		}
+	}
+
+	var sources []*tableInfo
+	for _, ref := range refs {
+		if nested[tokenIndex(tokens, ref.Pos)] {
+			continue
+		}
+		if ref.Schema == "" {
+			return nil
+		}
+
+		info, err := c.lookup(ref)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, info)
+	}
+
+	// Column aliases of the SELECT list can be used in ORDER BY
+	aliases := map[string]bool{}
+	for idx := 1; idx < len(tokens); idx++ {
+		if isName(tokens[idx]) && (tokens[idx-1].Is("AS") || endsOperand(tokens[idx-1])) {
+			aliases[normalize(tokens[idx])] = true
+		}
+	}
+
+	checked := false
+	depth := 0
+	for idx := 0; idx < len(tokens); idx++ {
+		token := tokens[idx]
+		switch {
+		case nested[idx]:
+			continue
+		case token.Type == sqlparser.Punct && token.Text == "(":
+			depth++
+			continue
+		case token.Type == sqlparser.Punct && token.Text == ")":
+			depth--
+			continue
+		}
+
+		// Clauses are only switched at the statement level, e.g. not by EXTRACT(YEAR FROM X)
+		if depth == 0 && token.Type == sqlparser.Ident {
+			switch token.Keyword() {
+			case "SELECT", "WHERE", "HAVING":
+				checked = token.Is("WHERE") || statement.Kind != sqlparser.KindDML
+				continue
+			case "GROUP", "ORDER":
+				if tokens[idx+1].Is("BY") {
+					checked = true
+					idx++
+					continue
+				}
+			case "FROM", "JOIN", "ON", "USING", "LIMIT", "OFFSET", "FETCH", "UNION", "EXCEPT", "INTERSECT", "SET":
+				checked = false
+				continue
+			}
+		}
+
+		if !checked || !isName(token) || idx == 0 || isDot(tokens[idx-1]) || isDot(tokens[idx+1]) {
+			continue
+		}
+		if token.Type == sqlparser.Ident && (sqlparser.IsReserved(token.Keyword()) || expressionWords[token.Keyword()]) {
+			continue
+		}
+
+		// Function names, typed literals such as DATE '2024-01-01' and aliases are not columns
+		next := tokens[idx+1]
+		if next.Text == "(" || next.Type == sqlparser.String || aliases[normalize(token)] {
+			continue
+		}
+
+		c.checkCase([]sqlparser.Token{token}, 0)
+		c.resolveColumn(sources, token)
+	}
+
+	return nil
+}
+
+// Report an unqualified column no statement table has, when the columns of all of them are known
+func (c *checker) resolveColumn(sources []*tableInfo, token sqlparser.Token) {
+	column := normalize(token)
+	found := 0
+	for _, info := range sources {
+		if info == nil || info.columns == nil {
+			return
+		}
+		if info.columns[column] {
+			found++
+		}
+	}
+
+	switch {
+	case found == 0:
+		c.report(token.Pos, Error, "column %s doesn't exist", column)
+	case found > 1:
+		c.report(token.Pos, Warning, "column %s is ambiguous, qualify it with its table", column)
+	}
+}
+
+func (c *checker) checkColumn(info *tableInfo, token sqlparser.Token) {
+	c.checkCase([]sqlparser.Token{token}, 0)
+	if info == nil || info.columns == nil {
+		return
+	}
+
+	if column := normalize(token); !info.columns[column] {
+		c.report(token.Pos, Error, "column %s doesn't exist", column)
+	}
+}
+
+// Warn about unquoted lower case names, which SxT upper cases
+func (c *checker) checkCase(tokens []sqlparser.Token, start int) {
+	for idx := start; idx <= nameEnd(tokens, start); idx += 2 {
+		token := tokens[idx]
+		if token.Type != sqlparser.Ident {
+			continue
+		}
+
+		if _, ok := helpers.CheckUpperCase(token.Text); !ok {
+			c.report(token.Pos, Warning, "%s is not upper case, SxT reads it as %s", token.Text, strings.ToUpper(token.Text))
+		}
+	}
+}
+
+// Columns of a CREATE TABLE column list starting at start. Constraints are skipped
+func definedColumns(tokens []sqlparser.Token, start int) map[string]bool {
+	if tokens[start].Text != "(" {
+		return nil
+	}
+
+	columns := map[string]bool{}
+	depth := 0
+	first := true
+	for _, token := range tokens[start:] {
+		switch {
+		case token.Type == sqlparser.Punct && token.Text == "(":
+			depth++
+			continue
+		case token.Type == sqlparser.Punct && token.Text == ")":
+			depth--
+			continue
+		case depth == 1 && token.Text == ",":
+			first = true
+			continue
+		}
+
+		if depth == 1 && first {
+			first = false
+			if isName(token) && !constraintKeywords[token.Keyword()] {
+				columns[normalize(token)] = true
+			}
+		}
+	}
+
+	return columns
+}
+
+var constraintKeywords = map[string]bool{"CONSTRAINT": true, "PRIMARY": true, "FOREIGN": true, "UNIQUE": true, "CHECK": true}
+
+// Unreserved words of expressions and ORDER BY which are not columns
+var expressionWords = map[string]bool{
+	"CURRENT_DATE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "LOCALTIME": true, "LOCALTIMESTAMP": true,
+	"INTERVAL": true, "NULLS": true, "FIRST": true, "LAST": true, "OVER": true, "PARTITION": true, "ROWS": true,
+	"RANGE": true, "UNBOUNDED": true, "PRECEDING": true, "FOLLOWING": true, "CURRENT": true, "ROW": true,
+	"FILTER": true, "ESCAPE": true, "ILIKE": true, "YEAR": true, "MONTH": true, "DAY": true, "HOUR": true,
+	"MINUTE": true, "SECOND": true,
+}
+
+// Reports if a token ends an operand, so a name after it is an alias
+func endsOperand(token sqlparser.Token) bool {
+	switch token.Type {
+	case sqlparser.Number, sqlparser.String, sqlparser.Param, sqlparser.QuotedIdent:
+		return true
+	case sqlparser.Punct:
+		return token.Text == ")"
+	case sqlparser.Ident:
+		return token.Is("END") || !sqlparser.IsReserved(token.Keyword()) && !expressionWords[token.Keyword()]
+	}
+
+	return false
+}
+
+// Index of the parenthesis closing the one at start
+func closingParen(tokens []sqlparser.Token, start int) int {
+	depth := 0
+	for idx := start; idx < len(tokens); idx++ {
+		if tokens[idx].Type != sqlparser.Punct {
+			continue
+		}
+		switch tokens[idx].Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return idx
+			}
+		}
+	}
+
+	return len(tokens) - 1
+}
+
+// Index of the token at a position
+func tokenIndex(tokens []sqlparser.Token, pos sqlparser.Position) int {
+	for idx, token := range tokens {
+		if token.Pos.Offset == pos.Offset {
+			return idx
+		}
+	}
+
+	return len(tokens) - 1
+}
+
+// Index of the last token of a dotted name starting at start
+func nameEnd(tokens []sqlparser.Token, start int) int {
+	end := start
+	for end+2 < len(tokens) && isDot(tokens[end+1]) && isName(tokens[end+2]) {
+		end += 2
+	}
+
+	return end
+}
+
+func hasTopLevel(tokens []sqlparser.Token, keyword string) bool {
+	depth := 0
+	for _, token := range tokens {
+		switch {
+		case token.Type == sqlparser.Punct && token.Text == "(":
+			depth++
+		case token.Type == sqlparser.Punct && token.Text == ")":
+			depth--
+		case depth == 0 && token.Is(keyword):
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasSequence(tokens []sqlparser.Token, keywords ...string) bool {
+	for idx := 0; idx+len(keywords) <= len(tokens); idx++ {
+		match := true
+		for offset, keyword := range keywords {
+			if !tokens[idx+offset].Is(keyword) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isName(token sqlparser.Token) bool {
+	return token.Type == sqlparser.Ident || token.Type == sqlparser.QuotedIdent
+}
+
+func isDot(token sqlparser.Token) bool {
+	return token.Type == sqlparser.Punct && token.Text == "."
+}
+
+// Name as SxT stores it: quoted names as written, others upper cased
+func normalize(token sqlparser.Token) string {
+	if token.Type == sqlparser.QuotedIdent {
+		return token.Value
+	}
+
+	return strings.ToUpper(token.Text)
+}
+
+func contains(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,171 @@
+package sqlvalidate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spaceandtimelabs/SxT-Go-SDK/discovery"
+	"github.com/spaceandtimelabs/SxT-Go-SDK/sqlcore"
+)
+
+// Catalog of fixed tables, counting lookups
+type mapCatalog struct {
+	tables  map[string]map[string][]string
+	lookups int
+}
+
+func (m *mapCatalog) Schemas() (schemas []string, err error) {
+	m.lookups++
+	for schema := range m.tables {
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+func (m *mapCatalog) Tables(schema string) (tables []string, err error) {
+	m.lookups++
+	for table := range m.tables[schema] {
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (m *mapCatalog) Columns(schema, table string) ([]string, error) {
+	m.lookups++
+	return m.tables[schema][table], nil
+}
+
+func testCatalog() *mapCatalog {
+	return &mapCatalog{tables: map[string]map[string][]string{
+		"ETH": {
+			"BLOCKS": {"ID", "HASH", "N"},
+			"TX":     {"ID", "BLOCK_ID", "VALUE"},
+			"RECENT": nil,
+		},
+	}}
+}
+
+func TestValidate(t *testing.T) {
+	validator := New(testCatalog())
+
+	tests := []struct {
+		sqlText  string
+		problems []string
+	}{
+		{"SELECT B.ID, T.VALUE FROM ETH.BLOCKS B JOIN ETH.TX AS T ON B.ID = T.BLOCK_ID", nil},
+		{"SELECT B.IDX FROM ETH.BLOCKS B", []string{"1:10: error: column IDX doesn't exist"}},
+		{"SELECT * FROM ETH.BLOCK", []string{"1:15: error: ETH.BLOCK doesn't exist"}},
+		{"SELECT * FROM BTC.BLOCKS", []string{"1:15: error: schema BTC doesn't exist"}},
+		{"SELECT * FROM eth.blocks", []string{"1:15: warning: eth is not upper case, SxT reads it as ETH", "1:19: warning: blocks is not upper case, SxT reads it as BLOCKS"}},
+		{"SELECT R.ANYTHING FROM ETH.RECENT R", nil},
+		{"INSERT INTO ETH.TX (ID, VALU) VALUES (1, 2)", []string{"1:25: error: column VALU doesn't exist"}},
+		{"UPDATE ETH.TX SET VALUE = COALESCE(VALUE, 0), BLOK_ID = 1 WHERE ID = 1", []string{"1:47: error: column BLOK_ID doesn't exist"}},
+		{"DELETE FROM ETH.TX", []string{"1:13: error: DELETE without WHERE changes every row of ETH.TX"}},
+		{"SELECT * FROM ETH.BLOCKS; SELEC 1", []string{"1:27: error: unsupported statement \"SELEC\""}},
+		{"CREATE TABLE ETH.BLOCKS (ID BIGINT)", []string{"1:14: error: ETH.BLOCKS already exists"}},
+		{"CREATE TABLE ETH.T2 (ID BIGINT, NAME VARCHAR(10), PRIMARY KEY (ID));\nINSERT INTO ETH.T2 (ID, NAME, AGE) VALUES (1, 'a', 2);\nALTER TABLE ETH.T2 ADD AGE INT;\nINSERT INTO ETH.T2 (AGE) VALUES (1)",
+			[]string{"2:31: error: column AGE doesn't exist"}},
+		{"DROP TABLE ETH.TX; SELECT * FROM ETH.TX", []string{"1:34: error: ETH.TX doesn't exist"}},
+		{"CREATE SCHEMA BTC; CREATE TABLE BTC.T (ID INT); DROP TABLE IF EXISTS BTC.U", nil},
+		{"SELECT NOPE FROM ETH.BLOCKS", []string{"1:8: error: column NOPE doesn't exist"}},
+		{"SELECT ID FROM ETH.BLOCKS WHERE NOPE = 1 GROUP BY HASH ORDER BY N DESC", []string{"1:33: error: column NOPE doesn't exist"}},
+		{"SELECT HASH, COUNT(*) AS TOTAL, CAST(N AS BIGINT) FROM ETH.BLOCKS WHERE N > 1 AND CURRENT_DATE > DATE '2024-01-01' GROUP BY HASH ORDER BY TOTAL", nil},
+		{"SELECT HASH, BLOCK_ID, ID FROM ETH.BLOCKS B JOIN ETH.TX T ON B.ID = T.BLOCK_ID WHERE VALU > 0", []string{"1:24: warning: column ID is ambiguous, qualify it with its table", "1:86: error: column VALU doesn't exist"}},
+		{"SELECT ID FROM ETH.TX WHERE BLOCK_ID IN (SELECT ID FROM ETH.BLOCKS WHERE OTHER = 1)", nil},
+		{"SELECT ANYTHING FROM ETH.RECENT", nil},
+		{"DELETE FROM ETH.TX WHERE VALUEX = 1", []string{"1:26: error: column VALUEX doesn't exist"}},
+		{"SELECT id FROM ETH.BLOCKS", []string{"1:8: warning: id is not upper case, SxT reads it as ID"}},
+	}
+
+	for _, test := range tests {
+		problems, err := validator.Validate(test.sqlText)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var messages []string
+		for _, problem := range problems {
+			messages = append(messages, problem.String())
+		}
+		if !reflect.DeepEqual(messages, test.problems) {
+			t.Errorf("%s: expected %q, got %q", test.sqlText, test.problems, messages)
+		}
+	}
+}
+
+func TestDiscoveryCatalog(t *testing.T) {
+	created := false
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch request.URL.Path {
+		case "/sql/ddl":
+			created = true
+			w.Write([]byte("[]"))
+		case "/discover/schema":
+			w.Write([]byte(`[{"schema": "ETH"}]`))
+		case "/discover/table":
+			if created {
+				w.Write([]byte(`[{"schema": "ETH", "table": "NEW"}]`))
+			} else {
+				w.Write([]byte("[]"))
+			}
+		case "/discover/table/column":
+			w.Write([]byte(`[{"column": "ID"}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("BASEURL_GENERAL", server.URL)
+	t.Setenv("BASEURL_DISCOVERY", server.URL)
+
+	discovery.UseCache(discovery.NewCache(time.Minute))
+	defer discovery.UseCache(nil)
+
+	client := sqlcore.NewClient("test")
+	client.Preflight = New(DiscoveryCatalog{}).Preflight
+
+	var validationErr *ValidationError
+	if err := client.DML(context.Background(), "INSERT INTO ETH.NEW (ID) VALUES (1)", nil); !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	// The cached table list is dropped by the DDL
+	if err := client.DDL(context.Background(), "CREATE TABLE ETH.NEW (ID BIGINT PRIMARY KEY)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DML(context.Background(), "INSERT INTO ETH.NEW (ID) VALUES (1)", nil); err != nil {
+		t.Errorf("expected the new table to be seen, got %v", err)
+	}
+}
+
+func TestClientPreflight(t *testing.T) {
+	t.Setenv("BASEURL_GENERAL", "http://gateway.test")
+
+	client := sqlcore.NewClient("test")
+	client.HTTPClient = &http.Client{Transport: failingTransport{t}}
+	client.Preflight = New(testCatalog()).Preflight
+
+	var validationErr *ValidationError
+	if err := client.DML(context.Background(), "DELETE FROM ETH.TX", nil); !errors.As(err, &validationErr) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+type failingTransport struct {
+	t *testing.T
+}
+
+func (f failingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	f.t.Error("expected no request")
+	return nil, errors.New("unexpected request")
+}
@@ -115,3 +115,41 @@ func getSecretsManagerClient() *secretsmanager.Client {
 func awsAuthenticate() (cfg aws.Config, err error) {
 	return config.LoadDefaultConfig(context.TODO())
 }
+
+// Write registered biscuits to aws secrets manager
+// Secret to be stored as `userId`-biscuits, created on first write
+func AwsWriteBiscuits(userId string, biscuits []byte) (status bool) {
+	secretsManagerClient := getSecretsManagerClient()
+	secretId := userId + "-biscuits"
+
+	_, err := secretsManagerClient.PutSecretValue(context.TODO(), &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(secretId),
+		SecretString: aws.String(string(biscuits)),
+	})
+	if err == nil {
+		return true
+	}
+
+	_, err = secretsManagerClient.CreateSecret(context.TODO(), &secretsmanager.CreateSecretInput{
+		Description:  aws.String("Biscuits for " + userId),
+		Name:         aws.String(secretId),
+		SecretString: aws.String(string(biscuits)),
+	})
+
+	return err == nil
+}
+
+// Read registered biscuits from aws secrets manager
+func AwsReadBiscuits(userId string) (biscuits []byte, status bool) {
+	secretsManagerClient := getSecretsManagerClient()
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(userId + "-biscuits"),
+	}
+
+	secret, err := secretsManagerClient.GetSecretValue(context.TODO(), input)
+	if err != nil || secret.SecretString == nil {
+		return nil, false
+	}
+
+	return []byte(*secret.SecretString), true
+}
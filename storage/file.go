@@ -66,3 +66,28 @@ func FileReadSession(userId string) (sessionStruct FileSessionStruct, status boo
 
 	return sessionStruct, true
 }
+
+// Write registered biscuits to file, next to the session file of `userId`
+// Note: biscuits grant access to your tables. Keep the file private
+func FileWriteBiscuits(userId string, biscuits []byte) (status bool) {
+	filepath := "./tmp/" + userId + "-biscuits.json"
+	onlyOwnerCanReadWrite := fs.FileMode(0600)
+
+	err := os.WriteFile(filepath, biscuits, onlyOwnerCanReadWrite)
+	if err != nil {
+		log.Println(err.Error())
+		return false
+	}
+
+	return true
+}
+
+// Read registered biscuits from file
+func FileReadBiscuits(userId string) (biscuits []byte, status bool) {
+	content, err := os.ReadFile("./tmp/" + userId + "-biscuits.json")
+	if err != nil {
+		return nil, false
+	}
+
+	return content, true
+}
@@ -162,7 +162,7 @@ func SQLAPIs(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) (err er
 	// DDL
 	// Only for create queries
 	// For ALTER and DROP, use sqlcore.DDL()
-	sqlcore.CreateTable("CREATE TABLE ETH.TESTTABLE106 (id INT PRIMARY KEY, test VARCHAR)", "ALL", originApp, mb, publicKey)
+	errMsg, status = sqlcore.CreateTable("CREATE TABLE ETH.TESTTABLE106 (id INT PRIMARY KEY, test VARCHAR)", string(sqlcore.AccessPermissioned), originApp, mb, publicKey)
 	if !status {
 		return errors.New(errMsg)
 	}
@@ -204,7 +204,7 @@ func DiscoveryAPIs()(err error){
 	*************************************/
 
 	// List Schemas
-	_, errMsg, status := discovery.ListSchemas("ALL", "")
+	_, errMsg, status := discovery.ListSchemas(discovery.ScopeAll, "")
 	if !status {
 		return errors.New(errMsg)
 	}
@@ -216,7 +216,7 @@ func DiscoveryAPIs()(err error){
 	// 3. PRIVATE = tables created by the requesting user
 	// 4. SUBSCRIPTION =  include all resources the requesting user can see
 	// Note: schema and table names are case sensitive. Upper cased
-	_, errMsg, status = discovery.ListTables("ETH", "ALL", "")
+	_, errMsg, status = discovery.ListTables("ETH", discovery.ScopeAll, "")
 	if !status {
 		return errors.New(errMsg)
 	}
@@ -244,7 +244,7 @@ func DiscoveryAPIs()(err error){
 
 	// List table relations for a schema and scope
 	// Note: schema and table names are case sensitive. Upper cased
-	_, errMsg, status = discovery.ListTableRelations("ETH", "PRIVATE")
+	_, errMsg, status = discovery.ListTableRelations("ETH", discovery.ScopePrivate)
 	if !status {
 		return errors.New(errMsg)
 	}
@@ -264,9 +264,9 @@ func DiscoveryAPIs()(err error){
 	}
 
 	// List views for a view name for a owner
-	// Second parameter can be "", "true" or "false". It accepts a string and not a boolean
+	// Second parameter is discovery.AllViews, discovery.OwnedViews or discovery.SharedViews
 	// Note: schema and table names are case sensitive. Upper cased
-	_, errMsg, status = discovery.ListViews("SOME_VIEW_NAME", "")
+	_, errMsg, status = discovery.ListViews("SOME_VIEW_NAME", discovery.AllViews)
 	if !status {
 		return errors.New(errMsg)
 	}